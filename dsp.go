@@ -48,6 +48,34 @@ type Resetter interface {
 	Reset()
 }
 
+// The Contexter interface is used to set the stream context on processors.
+type Contexter interface {
+	SetContext(*Context)
+}
+
+// Metadata is a set of named values associated with a stream.
+type Metadata map[string]interface{}
+
+// String returns the value for key as a string. Returns false if the key
+// does not exist or the value is not a string.
+func (m Metadata) String(key string) (string, bool) {
+	v, ok := m[key]
+	if !ok {
+		return "", false
+	}
+	s, ok := v.(string)
+	return s, ok
+}
+
+// Context holds information about the stream being processed, for example,
+// the ID and metadata of the current utterance.
+type Context struct {
+	// ID is the stream identifier.
+	ID string
+	// Meta has the stream metadata.
+	Meta Metadata
+}
+
 // ProcFunc is the type used to implement processing functions.
 type ProcFunc func(int, ...Processer) (Value, error)
 
@@ -56,6 +84,7 @@ type Proc struct {
 	f      ProcFunc
 	inputs []Processer
 	cache  *cache
+	ctx    *Context
 }
 
 // NewProc creates a new Proc.
@@ -96,6 +125,16 @@ func (bp *Proc) Get(idx int) (Value, error) {
 	return nil, ErrNoFunc
 }
 
+// SetContext sets the stream context.
+func (bp *Proc) SetContext(ctx *Context) {
+	bp.ctx = ctx
+}
+
+// Context returns the stream context. Returns nil if the context was not set.
+func (bp *Proc) Context() *Context {
+	return bp.ctx
+}
+
 // SetCache sets the value in the cache.
 func (bp *Proc) SetCache(idx int, val Value) {
 	bp.cache.set(idx, val)
@@ -135,6 +174,7 @@ type OneProc struct {
 	f      OneProcFunc
 	inputs []Processer
 	cache  Value
+	ctx    *Context
 }
 
 // NewOneProc creates a new Proc.
@@ -168,6 +208,16 @@ func (bp *OneProc) Get() (Value, error) {
 	return nil, ErrNoFunc
 }
 
+// SetContext sets the stream context.
+func (bp *OneProc) SetContext(ctx *Context) {
+	bp.ctx = ctx
+}
+
+// Context returns the stream context. Returns nil if the context was not set.
+func (bp *OneProc) Context() *Context {
+	return bp.ctx
+}
+
 // Inputs returns the input processors.
 func (bp *OneProc) Inputs() []Processer {
 	return bp.inputs
@@ -186,9 +236,11 @@ func (bp *OneProc) OneValuer(n int) OneValuer {
 // App defines a DSP application.
 type App struct {
 	// App name.
-	Name   string
-	procs  map[string]Node
-	inputs map[Node][]Node
+	Name    string
+	procs   map[string]Node
+	inputs  map[Node][]Node
+	layouts map[string]Layout
+	ctx     *Context
 }
 
 // Node is a node in the processor graph.
@@ -220,9 +272,10 @@ func (n Node) GetOne() (Value, error) {
 // NewApp returns a new app.
 func NewApp(name string) *App {
 	return &App{
-		Name:   name,
-		procs:  make(map[string]Node),
-		inputs: make(map[Node][]Node),
+		Name:    name,
+		procs:   make(map[string]Node),
+		inputs:  make(map[Node][]Node),
+		layouts: make(map[string]Layout),
 	}
 }
 
@@ -313,6 +366,63 @@ func (app *App) Reset() {
 	}
 }
 
+// SetContext sets the context of the stream to be processed. The context is passed to
+// processors that implement the Contexter interface. Should be called when a new stream
+// is loaded so processors and sinks can access the stream metadata.
+func (app *App) SetContext(ctx *Context) {
+	app.ctx = ctx
+	for _, node := range app.procs {
+		c, ok := node.typ.(Contexter)
+		if ok {
+			c.SetContext(ctx)
+		}
+	}
+}
+
+// Context returns the context of the stream being processed. Returns nil if
+// the context was not set.
+func (app *App) Context() *Context {
+	return app.ctx
+}
+
+// SetLayout sets the layout of the output vectors of a node. It overrides the layout
+// provided by the processor. (See Layout.) Returns an error if there is no node with that name.
+func (app *App) SetLayout(name string, l Layout) error {
+	if _, ok := app.procs[name]; !ok {
+		return fmt.Errorf("no processor named [%s] in builder graph", name)
+	}
+	app.layouts[name] = l
+	return nil
+}
+
+// Layout returns the layout of the output vectors of a node. If the layout was not set
+// using SetLayout, it is obtained from the processor if it implements the Layouter interface.
+// The layouts of the inputs passed to the processor are obtained in the same way.
+func (app *App) Layout(name string) (Layout, error) {
+	if l, ok := app.layouts[name]; ok {
+		return l, nil
+	}
+	node, ok := app.procs[name]
+	if !ok {
+		return nil, fmt.Errorf("no processor named [%s] in builder graph", name)
+	}
+	layouter, ok := node.typ.(Layouter)
+	if !ok {
+		return nil, fmt.Errorf("processor [%s] does not have a layout", name)
+	}
+	inputs := []string{}
+	layouts := []Layout{}
+	for _, in := range app.inputs[node] {
+		inputs = append(inputs, in.name)
+		l, err := app.Layout(in.name)
+		if err != nil {
+			l = nil
+		}
+		layouts = append(layouts, l)
+	}
+	return layouter.Layout(inputs, layouts)
+}
+
 func (app *App) String() string {
 
 	var buf bytes.Buffer
@@ -40,3 +40,30 @@ func TestGraph(t *testing.T) {
 		t.Log(i, v)
 	}
 }
+
+type ctxProc struct {
+	*Proc
+}
+
+func TestContext(t *testing.T) {
+
+	app := NewApp("test")
+	p := &ctxProc{NewProc(10, numbers)}
+	app.Add("numbers", p)
+	app.Add("mean", NewOneProc(nil))
+	if app.Context() != nil {
+		t.Fatal("expected nil context")
+	}
+	ctx := &Context{ID: "utt1", Meta: Metadata{"speaker": "spk1", "gain": 2.0}}
+	app.SetContext(ctx)
+	if p.Context() != ctx || app.Context() != ctx {
+		t.Fatal("context was not set")
+	}
+	spk, ok := p.Context().Meta.String("speaker")
+	if !ok || spk != "spk1" {
+		t.Fatalf("expected speaker spk1, got %v", p.Context().Meta)
+	}
+	if _, ok := p.Context().Meta.String("gain"); ok {
+		t.Fatal("expected false for non-string value")
+	}
+}
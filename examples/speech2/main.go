@@ -75,6 +75,7 @@ func main() {
 			continue
 		}
 		app.Reset()
+		app.SetContext(wavSource.NewContext())
 		log.Printf("processing waveform [%s] with %d frames, mean: %6.2f, sd: %6.2f", id, numFrames, wavSource.Mean(), wavSource.SD())
 		for i := 0; ; i++ {
 			v, e := out.Get(i)
@@ -0,0 +1,103 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Range is a named range of elements in a vector, from Start to End-1.
+type Range struct {
+	// Name of the range, typically the name of the node that produced the values.
+	Name  string `json:"name"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	// Names has an optional name for each element in the range.
+	Names []string `json:"names,omitempty"`
+}
+
+// Len returns the number of elements in the range.
+func (r Range) Len() int {
+	return r.End - r.Start
+}
+
+// Layout describes the elements of the output vectors of a processor as a list of
+// consecutive named ranges.
+type Layout []Range
+
+// The Layouter interface is implemented by processors that describe the layout of
+// their output vectors. Param inputs has the names of the input nodes and param layouts
+// has the layouts of the input nodes, a layout is nil if the input has no layout.
+// Returns an error if the layout is not known.
+type Layouter interface {
+	Layout(inputs []string, layouts []Layout) (Layout, error)
+}
+
+// Dim returns the dimension of the vectors.
+func (l Layout) Dim() int {
+	if len(l) == 0 {
+		return 0
+	}
+	return l[len(l)-1].End
+}
+
+// Range returns the range with the given name.
+func (l Layout) Range(name string) (Range, bool) {
+	for _, r := range l {
+		if r.Name == name {
+			return r, true
+		}
+	}
+	return Range{}, false
+}
+
+// Indices returns the indices of the elements selected by name. A name can be the name
+// of a range, which selects all its elements, or the name of an element.
+func (l Layout) Indices(names ...string) ([]int, error) {
+	var indices []int
+	for _, name := range names {
+		found := false
+		for _, r := range l {
+			if r.Name == name {
+				for i := r.Start; i < r.End; i++ {
+					indices = append(indices, i)
+				}
+				found = true
+				break
+			}
+			for i, n := range r.Names {
+				if n == name {
+					indices = append(indices, r.Start+i)
+					found = true
+					break
+				}
+			}
+			if found {
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no range or element named [%s] in layout", name)
+		}
+	}
+	return indices, nil
+}
+
+// Write writes the layout in JSON format.
+func (l Layout) Write(w io.Writer) error {
+	return json.NewEncoder(w).Encode(l)
+}
+
+// ReadLayout reads a layout in JSON format.
+func ReadLayout(r io.Reader) (Layout, error) {
+	var l Layout
+	if err := json.NewDecoder(r).Decode(&l); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
@@ -0,0 +1,59 @@
+package dsp
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestLayout(t *testing.T) {
+
+	l := Layout{
+		{Name: "energy", Start: 0, End: 1},
+		{Name: "cepstrum", Start: 1, End: 4, Names: []string{"c1", "c2", "c3"}},
+		{Name: "pitch", Start: 4, End: 6},
+	}
+	if l.Dim() != 6 {
+		t.Fatalf("expected dim 6, got %d", l.Dim())
+	}
+	if r, ok := l.Range("cepstrum"); !ok || r.Len() != 3 {
+		t.Fatalf("unexpected range: %v", r)
+	}
+	indices, err := l.Indices("pitch", "c2", "energy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(indices, []int{4, 5, 2, 0}) {
+		t.Fatalf("unexpected indices: %v", indices)
+	}
+	if _, err := l.Indices("foo"); err == nil {
+		t.Fatal("expected error for unknown name")
+	}
+
+	var buf bytes.Buffer
+	if err := l.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	l2, err := ReadLayout(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(l, l2) {
+		t.Fatalf("expected %v, got %v", l, l2)
+	}
+
+	app := NewApp("layout")
+	app.Add("numbers", NewProc(10, numbers))
+	if _, err := app.Layout("numbers"); err == nil {
+		t.Fatal("expected error for processor without layout")
+	}
+	if err := app.SetLayout("numbers", Layout{{Name: "n", Start: 0, End: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.SetLayout("foo", Layout{}); err == nil {
+		t.Fatal("expected error for unknown node")
+	}
+	if l, err := app.Layout("numbers"); err != nil || l.Dim() != 1 {
+		t.Fatalf("unexpected layout %v, error: %v", l, err)
+	}
+}
@@ -0,0 +1,241 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package proc
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+
+	"github.com/akualab/dsp"
+	narray "github.com/akualab/narray/na64"
+)
+
+// numFrames returns the number of frames of size winSize and step stepSize that fit in n samples.
+func numFrames(n, winSize, stepSize int) int {
+	if n < winSize {
+		return 0
+	}
+	return (n-winSize)/stepSize + 1
+}
+
+// checkGrid checks the frame grid parameters.
+func checkGrid(fs float64, winSize, stepSize int) error {
+	if fs <= 0 {
+		return fmt.Errorf("sampling rate must be positive, got %f", fs)
+	}
+	if winSize < 1 || stepSize < 1 {
+		return fmt.Errorf("window and step sizes must be positive, got win:%d, step:%d", winSize, stepSize)
+	}
+	return nil
+}
+
+/*
+GammatoneProc is a fourth order gammatone filterbank. The input is the entire waveform which
+is read from index zero of input 0. (As returned by sources with frame size zero.) The output
+for index idx is the mean energy of each band in the frame that starts at sample idx*stepSize
+and has winSize samples. Returns ErrOOB when the frame does not fit in the waveform.
+
+The impulse response of the filter with center frequency fc is:
+
+	g(t) = t^3 exp(-2 Pi b t) cos(2 Pi fc t)  with  b = 1.019 ERB(fc)
+
+where ERB(fc) = 24.7 (4.37 fc/1000 + 1) is the equivalent rectangular bandwidth of Glasberg
+and Moore. The filters are implemented in the time domain by shifting the band to zero
+frequency and applying a cascade of four first order complex IIR filters. The gain at the
+center frequency is one.
+
+To get center frequencies equally spaced on the ERB scale use FilterCenters with the ERB scale.
+*/
+type GammatoneProc struct {
+	fs       float64
+	centers  []float64
+	winSize  int
+	stepSize int
+	frames   []*narray.NArray
+	err      error
+	*dsp.Proc
+}
+
+// NewGammatoneProc returns a gammatone filterbank with the given center frequencies in Hertz.
+func NewGammatoneProc(fs float64, centers []float64, winSize, stepSize int) *GammatoneProc {
+	gp := &GammatoneProc{
+		fs:       fs,
+		centers:  centers,
+		winSize:  winSize,
+		stepSize: stepSize,
+		err:      checkGrid(fs, winSize, stepSize),
+		Proc:     dsp.NewProc(defaultBufSize, nil),
+	}
+	for _, fc := range centers {
+		if fc <= 0 || fc >= fs/2 {
+			gp.err = fmt.Errorf("center frequency must be between zero and fs/2, got %f", fc)
+		}
+	}
+	return gp
+}
+
+// Get implements the dsp.Framer interface.
+func (gp *GammatoneProc) Get(idx int) (dsp.Value, error) {
+	if gp.err != nil {
+		return nil, gp.err
+	}
+	if idx < 0 {
+		return nil, dsp.ErrOOB
+	}
+	if gp.frames == nil {
+		if err := gp.filter(); err != nil {
+			return nil, err
+		}
+	}
+	if idx >= len(gp.frames) {
+		return nil, dsp.ErrOOB
+	}
+	return gp.frames[idx], nil
+}
+
+// Reset implements the dsp.Resetter interface.
+func (gp *GammatoneProc) Reset() {
+	gp.frames = nil
+	gp.Proc.Reset()
+}
+
+// filter filters the entire waveform and computes the frame energies.
+func (gp *GammatoneProc) filter() error {
+	vec, err := dsp.Processers(gp.Inputs()).Get(0)
+	if err != nil {
+		return err
+	}
+	x := vec.(*narray.NArray).Data
+	nf := numFrames(len(x), gp.winSize, gp.stepSize)
+	gp.frames = make([]*narray.NArray, nf, nf)
+	for i := range gp.frames {
+		gp.frames[i] = narray.New(len(gp.centers))
+	}
+	y := make([]float64, len(x), len(x))
+	for j, fc := range gp.centers {
+		b := 1.019 * 24.7 * (4.37*fc/1000 + 1)
+		a := math.Exp(-2 * math.Pi * b / gp.fs)
+		w := 2 * math.Pi * fc / gp.fs
+		var s [4]complex128
+		for n, v := range x {
+			osc := cmplx.Rect(1, w*float64(n))
+			z := complex(v, 0) * cmplx.Conj(osc)
+			for k := range s {
+				s[k] = complex(1-a, 0)*z + complex(a, 0)*s[k]
+				z = s[k]
+			}
+			y[n] = 2 * real(z*osc)
+		}
+		for i := range gp.frames {
+			start := i * gp.stepSize
+			var egy float64
+			for _, v := range y[start : start+gp.winSize] {
+				egy += v * v
+			}
+			gp.frames[i].Data[j] = egy / float64(gp.winSize)
+		}
+	}
+	return nil
+}
+
+/*
+CQTProc computes the constant-Q transform. The input is the entire waveform which is read from
+index zero of input 0. The output for index idx is the energy of each frequency bin for the frame
+that starts at sample idx*stepSize and has winSize samples. Returns ErrOOB when the frame does not
+fit in the waveform.
+
+The center frequency of bin k is fmin * 2^(k/binsPerOctave) and the quality factor is
+Q = 1/(2^(1/binsPerOctave) - 1). The transform for bin k is computed using a Hann windowed kernel of
+length N_k = Q fs / f_k centered on the frame:
+
+	         1   N_k-1
+	X[k] = ---   sum  w_k[n] x[n] exp(-j 2 Pi Q n / N_k)
+	       N_k   n=0
+
+Kernels longer than the frame use samples around the frame. Samples outside of the waveform are zero.
+*/
+type CQTProc struct {
+	fs       float64
+	winSize  int
+	stepSize int
+	freqs    []float64
+	kernels  [][]complex128
+	err      error
+	*dsp.Proc
+}
+
+// NewCQTProc returns a constant-Q transform processor with numBins bins starting at fmin Hertz.
+func NewCQTProc(fs, fmin float64, binsPerOctave, numBins, winSize, stepSize int) *CQTProc {
+	cp := &CQTProc{
+		fs:       fs,
+		winSize:  winSize,
+		stepSize: stepSize,
+		err:      checkGrid(fs, winSize, stepSize),
+	}
+	cp.Proc = dsp.NewProc(defaultBufSize, cp.transform)
+	if cp.err != nil {
+		return cp
+	}
+	if binsPerOctave < 1 || numBins < 1 {
+		cp.err = fmt.Errorf("bins per octave and number of bins must be positive, got %d, %d", binsPerOctave, numBins)
+		return cp
+	}
+	fmax := fmin * math.Pow(2, float64(numBins-1)/float64(binsPerOctave))
+	if fmin <= 0 || fmax >= fs/2 {
+		cp.err = fmt.Errorf("bin frequencies must be between zero and fs/2, got %f to %f", fmin, fmax)
+		return cp
+	}
+	q := 1 / (math.Pow(2, 1/float64(binsPerOctave)) - 1)
+	cp.freqs = make([]float64, numBins, numBins)
+	cp.kernels = make([][]complex128, numBins, numBins)
+	for k := range cp.kernels {
+		fk := fmin * math.Pow(2, float64(k)/float64(binsPerOctave))
+		nk := int(math.Ceil(q * fs / fk))
+		kern := make([]complex128, nk, nk)
+		for n := range kern {
+			w := 0.5 - 0.5*math.Cos(2*math.Pi*float64(n)/float64(nk))
+			kern[n] = cmplx.Rect(w/float64(nk), -2*math.Pi*q*float64(n)/float64(nk))
+		}
+		cp.freqs[k] = fk
+		cp.kernels[k] = kern
+	}
+	return cp
+}
+
+func (cp *CQTProc) transform(idx int, in ...dsp.Processer) (dsp.Value, error) {
+	if cp.err != nil {
+		return nil, cp.err
+	}
+	vec, err := dsp.Processers(in).Get(0)
+	if err != nil {
+		return nil, err
+	}
+	x := vec.(*narray.NArray).Data
+	if idx >= numFrames(len(x), cp.winSize, cp.stepSize) {
+		return nil, dsp.ErrOOB
+	}
+	center := idx*cp.stepSize + cp.winSize/2
+	v := narray.New(len(cp.kernels))
+	for k, kern := range cp.kernels {
+		start := center - len(kern)/2
+		var sum complex128
+		for n, c := range kern {
+			i := start + n
+			if i < 0 || i >= len(x) {
+				continue
+			}
+			sum += complex(x[i], 0) * c
+		}
+		v.Data[k] = real(sum)*real(sum) + imag(sum)*imag(sum)
+	}
+	return v, nil
+}
+
+// Frequencies returns the center frequencies of the CQT bins in Hertz.
+func (cp *CQTProc) Frequencies() []float64 {
+	return cp.freqs
+}
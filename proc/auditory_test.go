@@ -0,0 +1,101 @@
+package proc
+
+import (
+	"math"
+	"testing"
+
+	"github.com/akualab/dsp"
+	narray "github.com/akualab/narray/na64"
+)
+
+func sine(n int, fs, freq float64) []float64 {
+	x := make([]float64, n)
+	for i := range x {
+		x[i] = math.Sin(2 * math.Pi * freq * float64(i) / fs)
+	}
+	return x
+}
+
+func maxIndex(data []float64) int {
+	var m int
+	for i, v := range data {
+		if v > data[m] {
+			m = i
+		}
+	}
+	return m
+}
+
+func TestGammatone(t *testing.T) {
+
+	fs := 16000.0
+	centers := []float64{250, 500, 1000, 2000, 4000}
+	x := sine(8000, fs, 1000)
+	app := dsp.NewApp("gammatone")
+	out := app.Chain(
+		app.Add("gammatone", NewGammatoneProc(fs, centers, 400, 160)),
+		app.Add("wav", wavSP(x)),
+	)
+	var n int
+	for ; ; n++ {
+		v, err := out.Get(n)
+		if err == dsp.ErrOOB {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data := v.(*narray.NArray).Data
+		if m := maxIndex(data); m != 2 {
+			t.Fatalf("frame %d: expected max energy in band 2, got %d", n, m)
+		}
+		// Skip the onset, unity gain at the center frequency.
+		if n > 5 {
+			compareFloats(t, 0.5, data[2], "band energy", 0.01)
+		}
+	}
+	if n != numFrames(len(x), 400, 160) {
+		t.Fatalf("expected %d frames, got %d", numFrames(len(x), 400, 160), n)
+	}
+
+	// Errors.
+	if _, err := NewGammatoneProc(fs, []float64{9000}, 400, 160).Get(0); err == nil {
+		t.Fatal("expected error for center frequency above fs/2")
+	}
+}
+
+func TestCQT(t *testing.T) {
+
+	fs := 16000.0
+	x := sine(8000, fs, 440)
+	cqt := NewCQTProc(fs, 110, 12, 48, 400, 160)
+	freqs := cqt.Frequencies()
+	compareFloats(t, 440, freqs[24], "bin frequency", 1e-9)
+
+	app := dsp.NewApp("cqt")
+	out := app.Chain(
+		app.Add("log", Log()),
+		app.Add("cqt", cqt),
+		app.Add("wav", wavSP(x)),
+	)
+	v, err := out.Get(20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := v.(*narray.NArray).Data
+	if len(data) != 48 {
+		t.Fatalf("expected 48 bins, got %d", len(data))
+	}
+	if m := maxIndex(data); m != 24 {
+		t.Fatalf("expected max energy in bin 24, got %d", m)
+	}
+	// Amplitude of a unit sine with a Hann kernel is 1/4.
+	compareFloats(t, math.Log(1.0/16), data[24], "log energy", 0.01)
+
+	if _, err := out.Get(numFrames(len(x), 400, 160)); err != dsp.ErrOOB {
+		t.Fatalf("expected ErrOOB, got %v", err)
+	}
+	if _, err := NewCQTProc(fs, 110, 12, 100, 400, 160).Get(0); err == nil {
+		t.Fatal("expected error for bins above fs/2")
+	}
+}
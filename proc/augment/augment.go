@@ -0,0 +1,329 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package augment provides processors to perturb waveforms for data augmentation.
+
+The processors operate on the whole waveform, that is, the input must return all the
+samples for index zero (for example, wav.SourceProc with frameSize=0). The output is also
+the whole waveform at index zero so augmentation processors can be inserted between a source
+and proc.WindowProc:
+
+	app.Chain(
+	  app.Add("windowed", proc.NewWindowProc(80, 205, proc.Hamming, true)),
+	  app.Add("reverb", augment.NewReverbProc(rir)),
+	  app.Add("gain", augment.NewGainProc(-6, 6, seed)),
+	  app.Add("wav", source),
+	)
+
+NoiseProc has two inputs, the waveform and the noise:
+
+	app.Connect(
+	  app.Add("noisy", augment.NewNoiseProc(10, seed)),
+	  app.NodeByName("wav"),
+	  app.Add("noise", noiseSource),
+	)
+
+Random perturbations are deterministic given a seed. The random number generator is
+initialized once using the seed and is used sequentially for each waveform. When the
+stream context is set (see dsp.App.SetContext), the generator is initialized for each
+waveform using the seed and the stream ID so the results for a waveform do not depend
+on the order in which waveforms are processed. (DitherProc initializes the generator
+using the seed when it is reset so the dither for a waveform does not depend on its
+position in the stream.)
+*/
+package augment
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+
+	"github.com/akualab/dsp"
+	"github.com/akualab/dsp/proc"
+	narray "github.com/akualab/narray/na64"
+)
+
+// base has common functionality for augmentation processors.
+type base struct {
+	seed int64
+	rng  *rand.Rand
+	*dsp.Proc
+}
+
+func newBase(seed int64) base {
+	return base{
+		seed: seed,
+		rng:  rand.New(rand.NewSource(seed)),
+		Proc: dsp.NewProc(1, nil),
+	}
+}
+
+// rand returns the random number generator for the current waveform.
+func (b *base) rand() *rand.Rand {
+	ctx := b.Context()
+	if ctx == nil {
+		return b.rng
+	}
+	h := fnv.New64a()
+	h.Write([]byte(ctx.ID))
+	return rand.New(rand.NewSource(b.seed ^ int64(h.Sum64())))
+}
+
+// signal returns the whole waveform from input n.
+func (b *base) signal(idx, n int) ([]float64, error) {
+	if idx != 0 {
+		return nil, dsp.ErrOOB
+	}
+	if len(b.Inputs()) <= n {
+		return nil, fmt.Errorf("expected at least %d inputs, got %d", n+1, len(b.Inputs()))
+	}
+	v, err := dsp.Get(b.Inputs()[n], 0)
+	if err != nil {
+		return nil, err
+	}
+	return v.(*narray.NArray).Data, nil
+}
+
+// get returns the cached output or computes it using f.
+func (b *base) get(idx int, f func(x []float64, r *rand.Rand) ([]float64, error)) (dsp.Value, error) {
+	val, ok := b.GetCache(idx)
+	if ok {
+		return val, nil
+	}
+	x, err := b.signal(idx, 0)
+	if err != nil {
+		return nil, err
+	}
+	y, err := f(x, b.rand())
+	if err != nil {
+		return nil, err
+	}
+	v := narray.NewArray(y, len(y))
+	b.SetCache(idx, v)
+	return v, nil
+}
+
+// NoiseProc adds noise from a second input to the waveform at a target signal to noise ratio.
+// Input 0 is the waveform and input 1 is the noise waveform. (For example, a wav.SourceProc
+// that reads noise files.) The noise is repeated if it is shorter than the waveform. The start
+// position in the noise waveform is chosen at random.
+type NoiseProc struct {
+	snr float64
+	base
+}
+
+// NewNoiseProc returns a processor that adds noise at a signal to noise ratio of snr dB.
+func NewNoiseProc(snr float64, seed int64) *NoiseProc {
+	return &NoiseProc{
+		snr:  snr,
+		base: newBase(seed),
+	}
+}
+
+// Get implements the dsp.Framer interface.
+func (np *NoiseProc) Get(idx int) (dsp.Value, error) {
+	return np.get(idx, func(x []float64, r *rand.Rand) ([]float64, error) {
+		noise, err := np.signal(idx, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(noise) == 0 {
+			return nil, fmt.Errorf("noise waveform is empty")
+		}
+		offset := r.Intn(len(noise))
+		var ps, pn float64
+		for i, v := range x {
+			nv := noise[(i+offset)%len(noise)]
+			ps += v * v
+			pn += nv * nv
+		}
+		scale := 0.0
+		if pn > 0 {
+			scale = math.Sqrt(ps / (pn * math.Pow(10, np.snr/10)))
+		}
+		y := make([]float64, len(x), len(x))
+		for i, v := range x {
+			y[i] = v + scale*noise[(i+offset)%len(noise)]
+		}
+		return y, nil
+	})
+}
+
+// ReverbProc convolves the waveform with a room impulse response.
+// The output has the same length as the input. The convolution is computed using proc.RealFT.
+type ReverbProc struct {
+	rir []float64
+	base
+}
+
+// NewReverbProc returns a processor that convolves the waveform with the room impulse response rir.
+func NewReverbProc(rir []float64) *ReverbProc {
+	return &ReverbProc{
+		rir:  rir,
+		base: newBase(0),
+	}
+}
+
+// Get implements the dsp.Framer interface.
+func (rp *ReverbProc) Get(idx int) (dsp.Value, error) {
+	return rp.get(idx, func(x []float64, r *rand.Rand) ([]float64, error) {
+		return proc.Convolve(x, rp.rir)[:len(x)], nil
+	})
+}
+
+// SpeedProc changes the speed of the waveform by resampling. A speed factor greater than one
+// makes the waveform shorter and raises the pitch. For each waveform, the speed factor is chosen
+// at random from the list of factors. (Use a single factor for a fixed perturbation.)
+type SpeedProc struct {
+	factors []float64
+	base
+}
+
+// NewSpeedProc returns a speed perturbation processor. For example, to replicate the
+// common 3-way speed perturbation use:
+//
+//	NewSpeedProc(seed, 0.9, 1.0, 1.1)
+func NewSpeedProc(seed int64, factors ...float64) *SpeedProc {
+	return &SpeedProc{
+		factors: factors,
+		base:    newBase(seed),
+	}
+}
+
+// Get implements the dsp.Framer interface.
+func (sp *SpeedProc) Get(idx int) (dsp.Value, error) {
+	return sp.get(idx, func(x []float64, r *rand.Rand) ([]float64, error) {
+		if len(sp.factors) == 0 {
+			return nil, fmt.Errorf("no speed factors")
+		}
+		f := sp.factors[r.Intn(len(sp.factors))]
+		if f <= 0 {
+			return nil, fmt.Errorf("speed factor must be positive, got %f", f)
+		}
+		return Resample(x, f), nil
+	})
+}
+
+// GainProc scales the waveform using a gain chosen at random for each waveform.
+type GainProc struct {
+	min, max float64
+	dB       bool
+	base
+}
+
+// NewGainProc returns a processor that applies a random gain uniformly distributed
+// between minDB and maxDB decibels.
+func NewGainProc(minDB, maxDB float64, seed int64) *GainProc {
+	return &GainProc{
+		min:  minDB,
+		max:  maxDB,
+		dB:   true,
+		base: newBase(seed),
+	}
+}
+
+// NewVolumeProc returns a volume perturbation processor that scales the waveform by a
+// factor uniformly distributed between min and max.
+func NewVolumeProc(min, max float64, seed int64) *GainProc {
+	return &GainProc{
+		min:  min,
+		max:  max,
+		base: newBase(seed),
+	}
+}
+
+// Get implements the dsp.Framer interface.
+func (gp *GainProc) Get(idx int) (dsp.Value, error) {
+	return gp.get(idx, func(x []float64, r *rand.Rand) ([]float64, error) {
+		g := gp.min + r.Float64()*(gp.max-gp.min)
+		if gp.dB {
+			g = math.Pow(10, g/20)
+		}
+		y := make([]float64, len(x), len(x))
+		for i, v := range x {
+			y[i] = v * g
+		}
+		return y, nil
+	})
+}
+
+// Resample changes the speed of x by factor using band-limited interpolation.
+// The length of the output is len(x)/factor.
+func Resample(x []float64, factor float64) []float64 {
+
+	const halfWidth = 16
+	n := int(float64(len(x)) / factor)
+	y := make([]float64, n, n)
+	// Lowpass cutoff relative to the input Nyquist frequency.
+	cutoff := 1.0
+	if factor > 1 {
+		cutoff = 1 / factor
+	}
+	w := float64(halfWidth) / cutoff
+	for i := range y {
+		t := float64(i) * factor
+		start := int(math.Ceil(t - w))
+		end := int(math.Floor(t + w))
+		if start < 0 {
+			start = 0
+		}
+		if end > len(x)-1 {
+			end = len(x) - 1
+		}
+		var sum float64
+		for j := start; j <= end; j++ {
+			d := t - float64(j)
+			// Hann windowed sinc.
+			win := 0.5 + 0.5*math.Cos(math.Pi*d/w)
+			sum += x[j] * cutoff * sinc(cutoff*d) * win
+		}
+		y[i] = sum
+	}
+	return y
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+// DitherProc adds Gaussian noise to the waveform to avoid digital silence, which produces
+// -Inf values in log features. Reference front-ends such as Kaldi add dither before computing
+// features. (Kaldi's default of 1.0 is for samples in the 16-bit integer range, use 1.0/32768
+// for samples scaled to the range [-1, 1).) The random number generator is initialized
+// using the seed on Reset so the same waveform gets the same dither.
+type DitherProc struct {
+	amount float64
+	base
+}
+
+// NewDitherProc returns a processor that adds Gaussian noise with standard deviation amount.
+func NewDitherProc(amount float64, seed int64) *DitherProc {
+	return &DitherProc{
+		amount: amount,
+		base:   newBase(seed),
+	}
+}
+
+// Get implements the dsp.Framer interface.
+func (dp *DitherProc) Get(idx int) (dsp.Value, error) {
+	return dp.get(idx, func(x []float64, r *rand.Rand) ([]float64, error) {
+		y := make([]float64, len(x), len(x))
+		for i, v := range x {
+			y[i] = v + dp.amount*r.NormFloat64()
+		}
+		return y, nil
+	})
+}
+
+// Reset implements the dsp.Resetter interface.
+func (dp *DitherProc) Reset() {
+	dp.rng = rand.New(rand.NewSource(dp.seed))
+	dp.Proc.Reset()
+}
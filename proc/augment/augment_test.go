@@ -0,0 +1,175 @@
+package augment
+
+import (
+	"math"
+	"testing"
+
+	"github.com/akualab/dsp"
+	"github.com/akualab/dsp/proc/gen"
+	narray "github.com/akualab/narray/na64"
+)
+
+func run(t *testing.T, p dsp.Processer, inputs ...[]float64) []float64 {
+	app := dsp.NewApp("augment")
+	nodes := []dsp.Node{}
+	for i, x := range inputs {
+		nodes = append(nodes, app.Add(string(rune('a'+i)), gen.NewSource("x", x)))
+	}
+	out := app.Connect(app.Add("out", p), nodes...)
+	v, err := out.Get(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := out.Get(1); err != dsp.ErrOOB {
+		t.Fatalf("expected ErrOOB for index 1, got %v", err)
+	}
+	return v.(*narray.NArray).Data
+}
+
+func TestNoise(t *testing.T) {
+
+	x := gen.Sine(8000, 8000, 300, 1, 0)
+	noise := gen.WhiteNoise(5000, 1, 3)
+	y := run(t, NewNoiseProc(5, 11), x, noise)
+	n := make([]float64, len(x))
+	for i := range x {
+		n[i] = y[i] - x[i]
+	}
+	snr := gen.SNR(x, n)
+	if math.Abs(snr-5) > 1e-9 {
+		t.Fatalf("expected snr 5, got %f", snr)
+	}
+
+	// Same seed, same result.
+	y2 := run(t, NewNoiseProc(5, 11), x, noise)
+	for i := range y {
+		if y[i] != y2[i] {
+			t.Fatalf("not deterministic at sample %d", i)
+		}
+	}
+}
+
+func TestReverb(t *testing.T) {
+
+	x := gen.WhiteNoise(1000, 1, 5)
+	rir := []float64{0, 0, 0.5, 0, 0.25}
+	y := run(t, NewReverbProc(rir), x)
+	if len(y) != len(x) {
+		t.Fatalf("expected %d samples, got %d", len(x), len(y))
+	}
+	for i := range y {
+		var exp float64
+		for k, h := range rir {
+			if i-k >= 0 {
+				exp += h * x[i-k]
+			}
+		}
+		if math.Abs(exp-y[i]) > 1e-9 {
+			t.Fatalf("sample %d - expected %f, got %f", i, exp, y[i])
+		}
+	}
+}
+
+func TestSpeed(t *testing.T) {
+
+	x := gen.Sine(8000, 8000, 500, 1, 0)
+	y := run(t, NewSpeedProc(1, 1.25), x)
+	if len(y) != 6400 {
+		t.Fatalf("expected 6400 samples, got %d", len(y))
+	}
+	// Frequency is scaled by the speed factor.
+	exp := gen.Sine(6400, 8000, 625, 1, 0)
+	for i := 100; i < len(y)-100; i++ {
+		if math.Abs(exp[i]-y[i]) > 0.01 {
+			t.Fatalf("sample %d - expected %f, got %f", i, exp[i], y[i])
+		}
+	}
+	y = run(t, NewSpeedProc(1, 1), x)
+	for i := range x {
+		if math.Abs(x[i]-y[i]) > 1e-9 {
+			t.Fatalf("factor 1 must not change the signal, sample %d", i)
+		}
+	}
+}
+
+func TestGain(t *testing.T) {
+
+	x := gen.WhiteNoise(100, 1, 5)
+	y := run(t, NewGainProc(-6, -6, 1), x)
+	g := math.Pow(10, -6.0/20)
+	for i := range x {
+		if math.Abs(x[i]*g-y[i]) > 1e-12 {
+			t.Fatalf("sample %d - expected %f, got %f", i, x[i]*g, y[i])
+		}
+	}
+	y = run(t, NewVolumeProc(0.5, 2, 1), x)
+	r := y[0] / x[0]
+	if r < 0.5 || r > 2 {
+		t.Fatalf("scale %f out of range", r)
+	}
+}
+
+func TestDither(t *testing.T) {
+
+	x := make([]float64, 20000)
+	y := run(t, NewDitherProc(0.01, 3), x)
+	var sum, sumsq float64
+	for _, v := range y {
+		sum += v
+		sumsq += v * v
+	}
+	mean := sum / float64(len(y))
+	sd := math.Sqrt(sumsq/float64(len(y)) - mean*mean)
+	if math.Abs(mean) > 5e-4 || math.Abs(sd-0.01) > 5e-4 {
+		t.Fatalf("unexpected noise stats, mean: %g, sd: %g", mean, sd)
+	}
+
+	// Same seed, same result.
+	y2 := run(t, NewDitherProc(0.01, 3), x)
+	for i := range y {
+		if y[i] != y2[i] {
+			t.Fatalf("not deterministic at sample %d", i)
+		}
+	}
+
+	// Without a context, the dither does not depend on the position in the stream.
+	app := dsp.NewApp("augment")
+	out := app.Connect(app.Add("dither", NewDitherProc(0.01, 3)), app.Add("wav", gen.NewSource("x", x)))
+	for k := 0; k < 2; k++ {
+		v, err := out.Get(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v.(*narray.NArray).Data[10] != y[10] {
+			t.Fatalf("waveform %d: expected %f, got %f", k, y[10], v.(*narray.NArray).Data[10])
+		}
+		app.Reset()
+	}
+}
+
+func TestContextSeed(t *testing.T) {
+
+	x := gen.WhiteNoise(100, 1, 5)
+	get := func(id string, warmup bool) float64 {
+		app := dsp.NewApp("augment")
+		src := app.Add("wav", gen.NewSource("x", x))
+		gain := app.Connect(app.Add("gain", NewGainProc(-10, 10, 7)), src)
+		if warmup {
+			app.SetContext(&dsp.Context{ID: "other"})
+			gain.Get(0)
+			app.Reset()
+		}
+		app.SetContext(&dsp.Context{ID: id})
+		v, err := gain.Get(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v.(*narray.NArray).Data[0]
+	}
+	if get("utt1", false) != get("utt1", true) {
+		t.Fatal("result must not depend on processing order when context is set")
+	}
+	if get("utt1", false) == get("utt2", false) {
+		t.Fatal("expected different gains for different ids")
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package proc
+
+import "github.com/akualab/dsp"
+
+// BoundaryPolicy determines the frames used beyond the boundaries of a stream.
+type BoundaryPolicy int
+
+const (
+	// Replicate repeats the first and last frames. (As in HTK and Kaldi.)
+	Replicate BoundaryPolicy = iota
+	// Reflect mirrors the stream around the first and last frames, x[-n] = x[n].
+	// Indices that are still out of bounds are replicated.
+	Reflect
+	// Zero uses zero vectors.
+	Zero
+)
+
+// boundary maps frame indices outside of the input stream to frames in the stream.
+// The length of the stream is found the first time an index beyond the last frame is used.
+type boundary struct {
+	policy BoundaryPolicy
+	in     interface {
+		Inputs() []dsp.Processer
+	}
+	n int // number of input frames, -1 if unknown
+}
+
+func (b *boundary) reset() {
+	b.n = -1
+}
+
+// index returns the index of the frame to use for idx. Returns false if a zero vector must be used.
+func (b *boundary) index(idx int) (int, bool, error) {
+	ok, err := b.valid(idx)
+	if err != nil || ok {
+		return idx, ok, err
+	}
+	var i int
+	switch b.policy {
+	case Zero:
+		return 0, false, nil
+	case Reflect:
+		if idx < 0 {
+			i = -idx
+		} else {
+			i = 2*(b.n-1) - idx
+		}
+		ok, err := b.valid(i)
+		if err != nil {
+			return 0, false, err
+		}
+		if !ok {
+			if i < 0 {
+				i = 0
+			} else {
+				i = b.n - 1
+			}
+		}
+	default:
+		if idx > 0 {
+			i = b.n - 1
+		}
+	}
+	return i, true, nil
+}
+
+// valid returns true if idx is the index of an input frame.
+func (b *boundary) valid(idx int) (bool, error) {
+	if idx < 0 {
+		return false, nil
+	}
+	if b.n >= 0 {
+		return idx < b.n, nil
+	}
+	_, err := dsp.Processers(b.in.Inputs()).Get(idx)
+	if err == nil {
+		return true, nil
+	}
+	if err != dsp.ErrOOB {
+		return false, err
+	}
+	return false, b.findLength(idx)
+}
+
+// findLength finds the number of input frames given an index that is out of bounds.
+func (b *boundary) findLength(oob int) error {
+	n := oob
+	for n > 0 {
+		_, err := dsp.Processers(b.in.Inputs()).Get(n - 1)
+		if err == nil {
+			break
+		}
+		if err != dsp.ErrOOB {
+			return err
+		}
+		n--
+	}
+	if n == 0 {
+		return dsp.ErrOOB
+	}
+	b.n = n
+	return nil
+}
@@ -0,0 +1,389 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package proc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/akualab/dsp"
+	narray "github.com/akualab/narray/na64"
+)
+
+// CMVNMode is the mode used to estimate the mean and variance in CMVNProc.
+type CMVNMode int
+
+const (
+	// UtteranceCMVN uses the mean and variance of the entire stream.
+	UtteranceCMVN CMVNMode = iota
+	// SlidingCMVN uses the mean and variance of the last WinSize frames. (As in MAProc.)
+	SlidingCMVN
+	// OnlineCMVN uses exponentially decaying estimates of the mean and variance.
+	OnlineCMVN
+	// FixedCMVN uses the global or per-speaker statistics in the Stats table.
+	FixedCMVN
+)
+
+// varFloor is the minimum variance used to normalize features.
+const varFloor = 1e-10
+
+// CMVNStats has the sufficient statistics to compute the mean and variance of feature vectors.
+type CMVNStats struct {
+	// Count is the number of frames.
+	Count float64 `json:"count"`
+	// Sum is the sum of the feature vectors.
+	Sum []float64 `json:"sum"`
+	// SumSq is the sum of the squares of the feature vectors.
+	SumSq []float64 `json:"sum_sq"`
+}
+
+// NewCMVNStats returns empty statistics for vectors of size dim.
+func NewCMVNStats(dim int) *CMVNStats {
+	return &CMVNStats{
+		Sum:   make([]float64, dim, dim),
+		SumSq: make([]float64, dim, dim),
+	}
+}
+
+// Add adds a feature vector to the statistics. Returns an error if the size of x
+// does not match the dimension of the statistics.
+func (s *CMVNStats) Add(x []float64) error {
+	if len(x) != len(s.Sum) {
+		return fmt.Errorf("mismatch in size of CMVN stats [%d] and input frame size [%d]", len(s.Sum), len(x))
+	}
+	s.Count++
+	for i, v := range x {
+		s.Sum[i] += v
+		s.SumSq[i] += v * v
+	}
+	return nil
+}
+
+// check returns an error if the statistics can't be used to normalize features.
+func (s *CMVNStats) check() error {
+	if s.Count <= 0 {
+		return fmt.Errorf("CMVN stats count must be positive, got %f", s.Count)
+	}
+	if len(s.Sum) != len(s.SumSq) {
+		return fmt.Errorf("mismatch in size of CMVN sum [%d] and sum of squares [%d]", len(s.Sum), len(s.SumSq))
+	}
+	return nil
+}
+
+// Merge adds the statistics in o.
+func (s *CMVNStats) Merge(o *CMVNStats) error {
+	if len(o.Sum) != len(s.Sum) {
+		return fmt.Errorf("mismatch in stats dimension, %d and %d", len(s.Sum), len(o.Sum))
+	}
+	s.Count += o.Count
+	for i := range s.Sum {
+		s.Sum[i] += o.Sum[i]
+		s.SumSq[i] += o.SumSq[i]
+	}
+	return nil
+}
+
+// Mean returns the mean vector.
+func (s *CMVNStats) Mean() []float64 {
+	m := make([]float64, len(s.Sum), len(s.Sum))
+	for i, v := range s.Sum {
+		m[i] = v / s.Count
+	}
+	return m
+}
+
+// Var returns the variance vector.
+func (s *CMVNStats) Var() []float64 {
+	v := make([]float64, len(s.Sum), len(s.Sum))
+	for i := range s.Sum {
+		m := s.Sum[i] / s.Count
+		v[i] = math.Max(s.SumSq[i]/s.Count-m*m, varFloor)
+	}
+	return v
+}
+
+// normalize subtracts the mean and optionally divides by the standard deviation.
+func (s *CMVNStats) normalize(x []float64, normVars bool) *narray.NArray {
+	out := narray.New(len(x))
+	for i, v := range x {
+		m := s.Sum[i] / s.Count
+		out.Data[i] = v - m
+		if normVars {
+			out.Data[i] /= math.Sqrt(math.Max(s.SumSq[i]/s.Count-m*m, varFloor))
+		}
+	}
+	return out
+}
+
+// AccumulateCMVN returns the statistics of all the frames in a stream.
+func AccumulateCMVN(in dsp.Framer) (*CMVNStats, error) {
+	var stats *CMVNStats
+	for i := 0; ; i++ {
+		vec, err := in.Get(i)
+		if err == dsp.ErrOOB {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		x := vec.(*narray.NArray).Data
+		if stats == nil {
+			stats = NewCMVNStats(len(x))
+		}
+		if err := stats.Add(x); err != nil {
+			return nil, fmt.Errorf("frame %d: %s", i, err)
+		}
+	}
+	if stats == nil {
+		return nil, fmt.Errorf("stream is empty")
+	}
+	return stats, nil
+}
+
+// CMVNTable has global and per-speaker statistics.
+type CMVNTable struct {
+	// Global statistics.
+	Global *CMVNStats `json:"global,omitempty"`
+	// Speakers has statistics per speaker.
+	Speakers map[string]*CMVNStats `json:"speakers,omitempty"`
+}
+
+// Write writes the table in JSON format.
+func (t *CMVNTable) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	return enc.Encode(t)
+}
+
+// WriteFile writes the table to a file in JSON format.
+func (t *CMVNTable) WriteFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := t.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadCMVNTable reads a table in JSON format. Returns an error if any of the
+// statistics has a non-positive count or mismatched sizes.
+func ReadCMVNTable(r io.Reader) (*CMVNTable, error) {
+	t := &CMVNTable{}
+	if err := json.NewDecoder(r).Decode(t); err != nil {
+		return nil, err
+	}
+	if t.Global != nil {
+		if err := t.Global.check(); err != nil {
+			return nil, fmt.Errorf("global stats: %s", err)
+		}
+	}
+	for spk, s := range t.Speakers {
+		if err := s.check(); err != nil {
+			return nil, fmt.Errorf("stats for speaker [%s]: %s", spk, err)
+		}
+	}
+	return t, nil
+}
+
+// ReadCMVNTableFile reads a table from a file in JSON format.
+func ReadCMVNTableFile(path string) (*CMVNTable, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadCMVNTable(f)
+}
+
+// lookup returns the statistics for a speaker. Falls back to the global statistics.
+func (t *CMVNTable) lookup(speaker string) (*CMVNStats, error) {
+	if s, ok := t.Speakers[speaker]; ok && len(speaker) > 0 {
+		return s, nil
+	}
+	if t.Global != nil {
+		return t.Global, nil
+	}
+	return nil, fmt.Errorf("no CMVN stats for speaker [%s] and no global stats", speaker)
+}
+
+/*
+CMVNProc is a cepstral mean and variance normalization processor. The output is the input vector
+minus the mean. If normVars is true, the result is divided by the standard deviation. See CMVNMode
+for the ways to estimate the mean and variance.
+
+When Stats is set, the speaker is read from the stream context metadata using key SpeakerKey
+(see dsp.App.SetContext). If there are no statistics for the speaker, the global statistics are used.
+In FixedCMVN mode, the statistics are used to normalize the features. In OnlineCMVN mode, the
+statistics are used as the initial estimate with a weight of PriorCount frames.
+*/
+type CMVNProc struct {
+	// WinSize is the number of frames used in SlidingCMVN mode. (Default is 300.)
+	WinSize int
+	// Alpha is the decay factor in OnlineCMVN mode. (Default is 0.995.)
+	Alpha float64
+	// Stats has the statistics for FixedCMVN and OnlineCMVN modes.
+	Stats *CMVNTable
+	// SpeakerKey is the metadata key that has the speaker ID. (Default is "speaker".)
+	SpeakerKey string
+	// PriorCount is the weight of the statistics in Stats in OnlineCMVN mode. (Default is 100.)
+	PriorCount float64
+
+	mode     CMVNMode
+	normVars bool
+	stats    *CMVNStats // utterance or running stats
+	next     int        // next frame in online mode
+	*dsp.Proc
+}
+
+// NewCMVNProc returns a CMVN processor.
+func NewCMVNProc(mode CMVNMode, normVars bool) *CMVNProc {
+	return &CMVNProc{
+		WinSize:    300,
+		Alpha:      0.995,
+		SpeakerKey: "speaker",
+		PriorCount: 100,
+		mode:       mode,
+		normVars:   normVars,
+		Proc:       dsp.NewProc(defaultBufSize, nil),
+	}
+}
+
+// Get implements the dsp.Framer interface.
+func (cp *CMVNProc) Get(idx int) (dsp.Value, error) {
+	if idx < 0 {
+		return nil, dsp.ErrOOB
+	}
+	val, ok := cp.GetCache(idx)
+	if ok {
+		return val, nil
+	}
+	in := dsp.Processers(cp.Inputs())
+	switch cp.mode {
+	case OnlineCMVN:
+		// Frames are processed in order to update the running estimates.
+		if idx < cp.next {
+			return nil, fmt.Errorf("frame %d is no longer in the cache, online CMVN can't go back", idx)
+		}
+		for cp.next < idx {
+			if _, err := cp.online(cp.next); err != nil {
+				return nil, err
+			}
+		}
+		return cp.online(idx)
+	case SlidingCMVN:
+		vec, err := in.Get(idx)
+		if err != nil {
+			return nil, err
+		}
+		start := idx - cp.WinSize + 1
+		if start < 0 {
+			start = 0
+		}
+		x := vec.(*narray.NArray).Data
+		stats := NewCMVNStats(len(x))
+		for j := start; j <= idx; j++ {
+			v, e := in.Get(j)
+			if e != nil {
+				return nil, e
+			}
+			if err := stats.Add(v.(*narray.NArray).Data); err != nil {
+				return nil, fmt.Errorf("frame %d: %s", j, err)
+			}
+		}
+		out := stats.normalize(x, cp.normVars)
+		cp.SetCache(idx, out)
+		return out, nil
+	case UtteranceCMVN, FixedCMVN:
+		vec, err := in.Get(idx)
+		if err != nil {
+			return nil, err
+		}
+		if cp.stats == nil {
+			if cp.mode == FixedCMVN {
+				cp.stats, err = cp.speakerStats()
+			} else {
+				cp.stats, err = AccumulateCMVN(in)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		x := vec.(*narray.NArray).Data
+		if len(x) != len(cp.stats.Sum) {
+			return nil, fmt.Errorf("mismatch in size of CMVN stats [%d] and input frame size [%d]", len(cp.stats.Sum), len(x))
+		}
+		out := cp.stats.normalize(x, cp.normVars)
+		cp.SetCache(idx, out)
+		return out, nil
+	default:
+		return nil, fmt.Errorf("unknown CMVN mode: %d", cp.mode)
+	}
+}
+
+// online updates the running estimates with frame idx and returns the normalized frame.
+func (cp *CMVNProc) online(idx int) (dsp.Value, error) {
+	vec, err := dsp.Processers(cp.Inputs()).Get(idx)
+	if err != nil {
+		return nil, err
+	}
+	x := vec.(*narray.NArray).Data
+	if cp.stats == nil {
+		cp.stats = NewCMVNStats(len(x))
+		if cp.Stats != nil {
+			prior, err := cp.speakerStats()
+			if err != nil {
+				return nil, err
+			}
+			if len(prior.Sum) != len(x) {
+				return nil, fmt.Errorf("mismatch in size of CMVN stats [%d] and input frame size [%d]", len(prior.Sum), len(x))
+			}
+			// Scale the prior to PriorCount frames.
+			c := cp.PriorCount / prior.Count
+			cp.stats.Count = cp.PriorCount
+			for i := range x {
+				cp.stats.Sum[i] = prior.Sum[i] * c
+				cp.stats.SumSq[i] = prior.SumSq[i] * c
+			}
+		}
+	}
+	s := cp.stats
+	s.Count *= cp.Alpha
+	for i := range s.Sum {
+		s.Sum[i] *= cp.Alpha
+		s.SumSq[i] *= cp.Alpha
+	}
+	if err := s.Add(x); err != nil {
+		return nil, fmt.Errorf("frame %d: %s", idx, err)
+	}
+	out := s.normalize(x, cp.normVars)
+	cp.SetCache(idx, out)
+	cp.next = idx + 1
+	return out, nil
+}
+
+// speakerStats returns the statistics in the Stats table for the speaker in the context.
+func (cp *CMVNProc) speakerStats() (*CMVNStats, error) {
+	if cp.Stats == nil {
+		return nil, fmt.Errorf("CMVN stats table is not set")
+	}
+	var speaker string
+	if ctx := cp.Context(); ctx != nil {
+		speaker, _ = ctx.Meta.String(cp.SpeakerKey)
+	}
+	return cp.Stats.lookup(speaker)
+}
+
+// Reset implements the dsp.Resetter interface.
+func (cp *CMVNProc) Reset() {
+	cp.stats = nil
+	cp.next = 0
+	cp.Proc.Reset()
+}
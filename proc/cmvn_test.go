@@ -0,0 +1,176 @@
+package proc
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/akualab/dsp"
+	narray "github.com/akualab/narray/na64"
+)
+
+func cmvnData(n int) [][]float64 {
+	r := rand.New(rand.NewSource(17))
+	data := make([][]float64, n)
+	for i := range data {
+		data[i] = []float64{3 + 2*r.NormFloat64(), -1 + 0.5*r.NormFloat64()}
+	}
+	return data
+}
+
+func streamValues(t *testing.T, p dsp.Framer) [][]float64 {
+	v := [][]float64{}
+	for i := 0; ; i++ {
+		vec, err := p.Get(i)
+		if err == dsp.ErrOOB {
+			return v
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		v = append(v, vec.(*narray.NArray).Data)
+	}
+}
+
+func TestCMVNUtterance(t *testing.T) {
+
+	data := cmvnData(500)
+	app := dsp.NewApp("cmvn")
+	out := app.Chain(
+		app.Add("cmvn", NewCMVNProc(UtteranceCMVN, true)),
+		app.Add("features", frames(data)),
+	)
+	stats, err := AccumulateCMVN(app.NodeByName("features"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	norm := streamValues(t, out)
+	if len(norm) != len(data) {
+		t.Fatalf("expected %d frames, got %d", len(data), len(norm))
+	}
+	check, _ := AccumulateCMVN(frames(norm).(dsp.Framer))
+	compareSliceFloat(t, []float64{0, 0}, check.Mean(), "mean", 1e-9)
+	compareSliceFloat(t, []float64{1, 1}, check.Var(), "var", 1e-9)
+	compareSliceFloat(t, []float64{3, -1}, stats.Mean(), "orig mean", 0.2)
+
+	// Mean only.
+	app = dsp.NewApp("cmn")
+	out = app.Chain(
+		app.Add("cmn", NewCMVNProc(UtteranceCMVN, false)),
+		app.Add("features", frames(data)),
+	)
+	check, _ = AccumulateCMVN(frames(streamValues(t, out)).(dsp.Framer))
+	compareSliceFloat(t, stats.Var(), check.Var(), "cmn var", 1e-9)
+}
+
+func TestCMVNSlidingAndOnline(t *testing.T) {
+
+	data := cmvnData(200)
+	app := dsp.NewApp("cmvn")
+	src := app.Add("features", frames(data))
+	sliding := NewCMVNProc(SlidingCMVN, true)
+	sliding.WinSize = 50
+	slidingNode := app.Connect(app.Add("sliding", sliding), src)
+	online := NewCMVNProc(OnlineCMVN, false)
+	online.Alpha = 1
+	onlineNode := app.Connect(app.Add("online", online), src)
+
+	v, err := slidingNode.Get(120)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats, _ := AccumulateCMVN(frames(data[71:121]).(dsp.Framer))
+	expected := stats.normalize(data[120], true).Data
+	compareSliceFloat(t, expected, v.(*narray.NArray).Data, "sliding", 1e-12)
+
+	// With alpha=1 the online estimate is the mean of all previous frames.
+	v, err = onlineNode.Get(99)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats, _ = AccumulateCMVN(frames(data[:100]).(dsp.Framer))
+	expected = stats.normalize(data[99], false).Data
+	compareSliceFloat(t, expected, v.(*narray.NArray).Data, "online", 1e-9)
+
+	// First frame has zero mean.
+	v, _ = onlineNode.Get(0)
+	compareSliceFloat(t, []float64{0, 0}, v.(*narray.NArray).Data, "online first", 1e-12)
+}
+
+func TestCMVNTable(t *testing.T) {
+
+	data := cmvnData(100)
+	stats, err := AccumulateCMVN(frames(data).(dsp.Framer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	global := NewCMVNStats(2)
+	global.Add([]float64{1, 1})
+	global.Add([]float64{-1, -1})
+	table := &CMVNTable{
+		Global:   global,
+		Speakers: map[string]*CMVNStats{"spk1": stats},
+	}
+	var buf bytes.Buffer
+	if err := table.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	table, err = ReadCMVNTable(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := dsp.NewApp("cmvn")
+	fixed := NewCMVNProc(FixedCMVN, true)
+	fixed.Stats = table
+	out := app.Chain(
+		app.Add("cmvn", fixed),
+		app.Add("features", frames(data)),
+	)
+
+	// Speaker stats.
+	app.SetContext(&dsp.Context{ID: "utt1", Meta: dsp.Metadata{"speaker": "spk1"}})
+	v, err := out.Get(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareSliceFloat(t, stats.normalize(data[10], true).Data, v.(*narray.NArray).Data, "speaker", 1e-12)
+
+	// Unknown speaker uses global stats.
+	app.Reset()
+	app.SetContext(&dsp.Context{ID: "utt2", Meta: dsp.Metadata{"speaker": "spk2"}})
+	v, err = out.Get(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareSliceFloat(t, data[10], v.(*narray.NArray).Data, "global", 1e-12)
+
+	// No stats.
+	table.Global = nil
+	app.Reset()
+	if _, err := out.Get(10); err == nil {
+		t.Fatal("expected error when there are no stats")
+	}
+
+	// Bad stats.
+	if err := global.Add([]float64{1, 2, 3}); err == nil {
+		t.Fatal("expected error for frame size mismatch")
+	}
+	for _, js := range []string{
+		`{"global":{"count":0,"sum":[1],"sum_sq":[1]}}`,
+		`{"speakers":{"spk1":{"count":2,"sum":[1,2],"sum_sq":[1]}}}`,
+	} {
+		if _, err := ReadCMVNTable(bytes.NewBufferString(js)); err == nil {
+			t.Fatalf("expected error for table %s", js)
+		}
+	}
+
+	// Merge.
+	if err := global.Merge(global); err != nil {
+		t.Fatal(err)
+	}
+	if global.Count != 4 || math.Abs(global.Var()[0]-1) > 1e-12 {
+		t.Fatalf("unexpected merged stats: %+v", global)
+	}
+}
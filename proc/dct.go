@@ -0,0 +1,260 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package proc
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/akualab/dsp"
+	narray "github.com/akualab/narray/na64"
+)
+
+// DCTType is the type of discrete cosine transform.
+type DCTType int
+
+// DCT types. The definitions match the ones used in FFTPACK and scipy.
+const (
+	// DCT1 is the type I DCT. Requires at least two points.
+	//  y[k] = x[0] + (-1)^k x[N-1] + 2 sum_{n=1}^{N-2} x[n] cos(Pi k n / (N-1))
+	DCT1 DCTType = iota + 1
+	// DCT2 is the type II DCT. (The DCT used to compute MFCCs.)
+	//  y[k] = 2 sum_{n=0}^{N-1} x[n] cos(Pi k (2n+1) / 2N)
+	DCT2
+	// DCT3 is the type III DCT. (The inverse of DCT2 up to a scale factor.)
+	//  y[k] = x[0] + 2 sum_{n=1}^{N-1} x[n] cos(Pi n (2k+1) / 2N)
+	DCT3
+	// DCT4 is the type IV DCT.
+	//  y[k] = 2 sum_{n=0}^{N-1} x[n] cos(Pi (2k+1) (2n+1) / 4N)
+	DCT4
+)
+
+/*
+GenerateDCTMatrix returns the n x m matrix of a DCT of the given type for input vectors of size m.
+Only the first n rows (output coefficients) are returned, n must not be larger than m.
+
+If ortho is true, the matrix is scaled so that the full transform is orthonormal. (The inverse is the
+transpose.) For DCT2 the scale factor is sqrt(1/4N) for k=0 and sqrt(1/2N) otherwise, this is
+the DCT used in Kaldi to compute MFCCs.
+*/
+func GenerateDCTMatrix(typ DCTType, n, m int, ortho bool) ([][]float64, error) {
+
+	if n < 1 || n > m {
+		return nil, fmt.Errorf("number of output coefficients must be between 1 and %d, got %d", m, n)
+	}
+	if typ == DCT1 && m < 2 {
+		return nil, fmt.Errorf("DCT type I requires at least two points, got %d", m)
+	}
+	if typ < DCT1 || typ > DCT4 {
+		return nil, fmt.Errorf("unknown DCT type: %d", typ)
+	}
+
+	N := float64(m)
+	edge := func(i int) float64 {
+		if i == 0 || i == m-1 {
+			return math.Sqrt(0.5)
+		}
+		return 1
+	}
+	dct := make([][]float64, n, n)
+	for k := 0; k < n; k++ {
+		dct[k] = make([]float64, m, m)
+		fk := float64(k)
+		for j := 0; j < m; j++ {
+			fj := float64(j)
+			var v float64
+			switch typ {
+			case DCT1:
+				v = math.Cos(math.Pi * fk * fj / (N - 1))
+				if ortho {
+					v *= math.Sqrt(2/(N-1)) * edge(k) * edge(j)
+				} else if j > 0 && j < m-1 {
+					v *= 2
+				}
+			case DCT2:
+				v = math.Cos(math.Pi * fk * (2*fj + 1) / (2 * N))
+				if ortho {
+					v *= math.Sqrt(2 / N)
+					if k == 0 {
+						v *= math.Sqrt(0.5)
+					}
+				} else {
+					v *= 2
+				}
+			case DCT3:
+				v = math.Cos(math.Pi * fj * (2*fk + 1) / (2 * N))
+				if ortho {
+					v *= math.Sqrt(2 / N)
+					if j == 0 {
+						v *= math.Sqrt(0.5)
+					}
+				} else if j > 0 {
+					v *= 2
+				}
+			case DCT4:
+				v = math.Cos(math.Pi * (2*fk + 1) * (2*fj + 1) / (4 * N))
+				if ortho {
+					v *= math.Sqrt(2 / N)
+				} else {
+					v *= 2
+				}
+			}
+			dct[k][j] = v
+		}
+	}
+	return dct, nil
+}
+
+// dctProc returns a processor that multiplies the input vectors by matrix t.
+func dctProc(t [][]float64, inSize int, err error) dsp.Processer {
+	return dsp.NewProc(defaultBufSize, func(idx int, in ...dsp.Processer) (dsp.Value, error) {
+		if err != nil {
+			return nil, err
+		}
+		input, e := dsp.Processers(in).Get(idx)
+		if e != nil {
+			return nil, e
+		}
+		data := input.(*narray.NArray).Data
+		if len(data) != inSize {
+			return nil, fmt.Errorf("mismatch in size [%d] and input frame size [%d]", inSize, len(data))
+		}
+		v := narray.New(len(t))
+		for i, row := range t {
+			for j, x := range data {
+				v.Data[i] += x * row[j]
+			}
+		}
+		return v, nil
+	})
+}
+
+/*
+TypedDCT returns the discrete cosine transform of the given type of the input vectors.
+The output has outSize coefficients. If keepC0 is false, the first coefficient (c0) is dropped and
+the output has coefficients 1 to outSize. (This is the behavior of DCT.)
+
+See GenerateDCTMatrix for the meaning of ortho.
+*/
+func TypedDCT(typ DCTType, inSize, outSize int, ortho, keepC0 bool) dsp.Processer {
+	n := outSize
+	if !keepC0 {
+		n++
+	}
+	t, err := GenerateDCTMatrix(typ, n, inSize, ortho)
+	if err == nil && !keepC0 {
+		t = t[1:]
+	}
+	return dctProc(t, inSize, err)
+}
+
+/*
+InverseDCT returns the inverse of the DCT computed by TypedDCT with the same type and scaling.
+The input vectors have inSize coefficients and the output vectors have outSize values, where
+outSize is the size of the vectors that were transformed. Missing coefficients are assumed to be zero,
+so a truncated cepstrum can be converted back to a smoothed log spectrum. If hasC0 is false, the input
+vectors start with coefficient 1 and c0 is assumed to be zero.
+*/
+func InverseDCT(typ DCTType, inSize, outSize int, ortho, hasC0 bool) dsp.Processer {
+	first := 0
+	if !hasC0 {
+		first = 1
+	}
+	if inSize+first > outSize {
+		return dctProc(nil, inSize, fmt.Errorf("number of coefficients [%d] is too large for output size [%d]", inSize, outSize))
+	}
+	t, err := GenerateDCTMatrix(typ, outSize, outSize, ortho)
+	if err != nil {
+		return dctProc(nil, inSize, err)
+	}
+	inv, err := invert(t)
+	if err != nil {
+		return dctProc(nil, inSize, err)
+	}
+	// Keep the columns that correspond to the input coefficients.
+	for i := range inv {
+		inv[i] = inv[i][first : first+inSize]
+	}
+	return dctProc(inv, inSize, nil)
+}
+
+// invert returns the inverse of square matrix a using Gauss-Jordan elimination with partial pivoting.
+func invert(a [][]float64) ([][]float64, error) {
+	n := len(a)
+	m := make([][]float64, n, n)
+	inv := make([][]float64, n, n)
+	for i := range a {
+		m[i] = append([]float64{}, a[i]...)
+		inv[i] = make([]float64, n, n)
+		inv[i][i] = 1
+	}
+	for c := 0; c < n; c++ {
+		p := c
+		for r := c + 1; r < n; r++ {
+			if math.Abs(m[r][c]) > math.Abs(m[p][c]) {
+				p = r
+			}
+		}
+		if math.Abs(m[p][c]) < 1e-12 {
+			return nil, fmt.Errorf("matrix is singular")
+		}
+		m[c], m[p] = m[p], m[c]
+		inv[c], inv[p] = inv[p], inv[c]
+		d := m[c][c]
+		for j := 0; j < n; j++ {
+			m[c][j] /= d
+			inv[c][j] /= d
+		}
+		for r := 0; r < n; r++ {
+			if r == c || m[r][c] == 0 {
+				continue
+			}
+			f := m[r][c]
+			for j := 0; j < n; j++ {
+				m[r][j] -= f * m[c][j]
+				inv[r][j] -= f * inv[c][j]
+			}
+		}
+	}
+	return inv, nil
+}
+
+/*
+LifterWeights returns the weights of the sinusoidal lifter used in HTK and Kaldi:
+
+	w[i] = 1 + (L/2) sin(Pi i / L)
+
+where i is the cepstral index. Returns n weights starting with cepstral index first.
+(Use first=0 if the cepstrum includes c0 and first=1 otherwise.)
+*/
+func LifterWeights(n int, L float64, first int) []float64 {
+	w := make([]float64, n, n)
+	for i := range w {
+		w[i] = 1 + L/2*math.Sin(math.Pi*float64(i+first)/L)
+	}
+	return w
+}
+
+// Lifter multiplies the input cepstral vectors by the sinusoidal lifter weights.
+// See LifterWeights for details.
+func Lifter(L float64, first int) dsp.Processer {
+	var w []float64
+	return dsp.NewProc(defaultBufSize, func(idx int, in ...dsp.Processer) (dsp.Value, error) {
+		vec, err := dsp.Processers(in).Get(idx)
+		if err != nil {
+			return nil, err
+		}
+		data := vec.(*narray.NArray).Data
+		if len(w) != len(data) {
+			w = LifterWeights(len(data), L, first)
+		}
+		v := narray.New(len(data))
+		for i, x := range data {
+			v.Data[i] = x * w[i]
+		}
+		return v, nil
+	})
+}
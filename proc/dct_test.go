@@ -0,0 +1,120 @@
+package proc
+
+import (
+	"testing"
+
+	"github.com/akualab/dsp"
+	narray "github.com/akualab/narray/na64"
+)
+
+func TestGenerateDCTMatrix(t *testing.T) {
+
+	x := []float64{1, 2, 3, 4}
+	for _, c := range []struct {
+		typ      DCTType
+		ortho    bool
+		expected []float64
+	}{
+		{DCT1, false, []float64{15, -4, 0, -1}},
+		{DCT2, false, []float64{20, -6.308644059797899, 0, -0.4483415291679655}},
+		{DCT2, true, []float64{5, -2.230442497387663, 0, -0.15851266778110726}},
+		{DCT3, false, []float64{11.999626276085152, -9.102943217749221, 2.617661843510648, -1.5143449018465822}},
+		{DCT4, false, []float64{10.181592984263283, -9.446695610035622, 5.010298174943416, -4.689564857456724}},
+	} {
+		dct, err := GenerateDCTMatrix(c.typ, 4, 4, c.ortho)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for k, row := range dct {
+			var y float64
+			for j, v := range row {
+				y += v * x[j]
+			}
+			compareFloats(t, c.expected[k], y, "dct", 1e-7)
+		}
+	}
+
+	// Orthonormal matrices.
+	for typ := DCT1; typ <= DCT4; typ++ {
+		dct, err := GenerateDCTMatrix(typ, 7, 7, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range dct {
+			for j := range dct {
+				var dot float64
+				for k := range dct[i] {
+					dot += dct[i][k] * dct[j][k]
+				}
+				expected := 0.0
+				if i == j {
+					expected = 1
+				}
+				compareFloats(t, expected, dot, "orthonormal", 1e-12)
+			}
+		}
+	}
+
+	if _, err := GenerateDCTMatrix(DCT2, 5, 4, true); err == nil {
+		t.Fatal("expected error for too many coefficients")
+	}
+}
+
+func TestInverseDCT(t *testing.T) {
+
+	x := []float64{0.3, -1.2, 2.5, 0.7, 1.1, -0.4, 0.9, 2.2}
+	for typ := DCT1; typ <= DCT4; typ++ {
+		for _, ortho := range []bool{false, true} {
+			app := dsp.NewApp("idct")
+			out := app.Chain(
+				app.Add("idct", InverseDCT(typ, 8, 8, ortho, true)),
+				app.Add("dct", TypedDCT(typ, 8, 8, ortho, true)),
+				app.Add("input", wavSP(x)),
+			)
+			v, err := out.Get(0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			compareSliceFloat(t, x, v.(*narray.NArray).Data, "idct", 1e-9)
+		}
+	}
+
+	// Without c0, the inverse has zero mean.
+	app := dsp.NewApp("idct")
+	out := app.Chain(
+		app.Add("idct", InverseDCT(DCT2, 7, 8, true, false)),
+		app.Add("dct", TypedDCT(DCT2, 8, 7, true, false)),
+		app.Add("input", wavSP(x)),
+	)
+	v, err := out.Get(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	y := v.(*narray.NArray).Data
+	var mean float64
+	for i := range x {
+		mean += x[i] / 8
+	}
+	for i := range x {
+		compareFloats(t, x[i]-mean, y[i], "idct no c0", 1e-9)
+	}
+}
+
+func TestLifter(t *testing.T) {
+
+	w := LifterWeights(3, 22, 0)
+	compareSliceFloat(t, []float64{1, 2.5654632, 4.0990581}, w, "lifter", 1e-6)
+	w = LifterWeights(2, 22, 1)
+	compareSliceFloat(t, []float64{2.5654632, 4.0990581}, w, "lifter no c0", 1e-6)
+
+	app := dsp.NewApp("lifter")
+	out := app.Chain(
+		app.Add("lifter", Lifter(22, 0)),
+		app.Add("input", wavSP([]float64{2, 2, 2})),
+	)
+	v, err := out.Get(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareSliceFloat(t, []float64{2, 5.1309264, 8.1981162}, v.(*narray.NArray).Data, "lifter proc", 1e-6)
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package proc
+
+import (
+	"fmt"
+
+	"github.com/akualab/dsp"
+	narray "github.com/akualab/narray/na64"
+)
+
+/*
+DeltaProc computes regression-based dynamic features. The delta of order one is:
+
+	         sum_{n=1}^{N} n (x[t+n] - x[t-n])
+	d[t] = -----------------------------------
+	               2 sum_{n=1}^{N} n^2
+
+where N is the window size. Higher order deltas are computed by applying the same formula to the
+deltas of the previous order. (As in HTK.) The output is the concatenation of the deltas of orders
+1 to order, the size of the output vector is order times the size of the input vector. Use Join to
+add the static features.
+
+Frames outside of the stream are determined using the boundary policy, which is applied to each order.
+The output has the same number of frames as the input.
+*/
+type DeltaProc struct {
+	window   int
+	order    int
+	boundary *boundary
+	norm     float64
+	deltas   []map[int][]float64
+	*dsp.Proc
+}
+
+// NewDeltaProc returns a new delta processor.
+func NewDeltaProc(window, order int, policy BoundaryPolicy) *DeltaProc {
+	dp := &DeltaProc{
+		window: window,
+		order:  order,
+		Proc:   dsp.NewProc(defaultBufSize, nil),
+	}
+	dp.boundary = &boundary{policy: policy, in: dp.Proc}
+	for i := 1; i <= window; i++ {
+		dp.norm += float64(i * i)
+	}
+	dp.norm *= 2
+	dp.Reset()
+	return dp
+}
+
+// Get implements the dsp.Framer interface.
+func (dp *DeltaProc) Get(idx int) (dsp.Value, error) {
+	if idx < 0 {
+		return nil, dsp.ErrOOB
+	}
+	val, ok := dp.GetCache(idx)
+	if ok {
+		return val, nil
+	}
+	if dp.window < 1 || dp.order < 1 {
+		return nil, fmt.Errorf("delta window and order must be positive, got window:%d, order:%d", dp.window, dp.order)
+	}
+	// Check that the frame exists.
+	if _, err := dp.frame(0, idx); err != nil {
+		return nil, err
+	}
+	v := []float64{}
+	for k := 1; k <= dp.order; k++ {
+		d, err := dp.frame(k, idx)
+		if err != nil {
+			return nil, err
+		}
+		v = append(v, d...)
+	}
+	res := narray.NewArray(v, len(v))
+	dp.SetCache(idx, res)
+	return res, nil
+}
+
+// frame returns the frame of order k for a valid index.
+func (dp *DeltaProc) frame(k, idx int) ([]float64, error) {
+	if k == 0 {
+		vec, err := dsp.Processers(dp.Inputs()).Get(idx)
+		if err != nil {
+			return nil, err
+		}
+		return vec.(*narray.NArray).Data, nil
+	}
+	if d, ok := dp.deltas[k][idx]; ok {
+		return d, nil
+	}
+	var d []float64
+	for n := 1; n <= dp.window; n++ {
+		plus, err := dp.boundaryFrame(k-1, idx+n)
+		if err != nil {
+			return nil, err
+		}
+		minus, err := dp.boundaryFrame(k-1, idx-n)
+		if err != nil {
+			return nil, err
+		}
+		if d == nil {
+			d = make([]float64, len(plus), len(plus))
+		}
+		for i := range d {
+			d[i] += float64(n) * (plus[i] - minus[i]) / dp.norm
+		}
+	}
+	dp.deltas[k][idx] = d
+	return d, nil
+}
+
+// boundaryFrame returns the frame of order k for any index using the boundary policy.
+func (dp *DeltaProc) boundaryFrame(k, idx int) ([]float64, error) {
+	i, ok, err := dp.boundary.index(idx)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		v, err := dp.frame(0, 0)
+		if err != nil {
+			return nil, err
+		}
+		return make([]float64, len(v), len(v)), nil
+	}
+	return dp.frame(k, i)
+}
+
+// Reset implements the dsp.Resetter interface.
+func (dp *DeltaProc) Reset() {
+	dp.boundary.reset()
+	dp.deltas = make([]map[int][]float64, dp.order+1, dp.order+1)
+	for k := range dp.deltas {
+		dp.deltas[k] = make(map[int][]float64)
+	}
+	dp.Proc.Reset()
+}
@@ -0,0 +1,80 @@
+package proc
+
+import (
+	"testing"
+
+	"github.com/akualab/dsp"
+	narray "github.com/akualab/narray/na64"
+)
+
+func ramp(n int, f func(float64) float64) [][]float64 {
+	data := make([][]float64, n)
+	for i := range data {
+		data[i] = []float64{f(float64(i))}
+	}
+	return data
+}
+
+func TestDeltaProc(t *testing.T) {
+
+	linear := ramp(10, func(x float64) float64 { return x })
+	for _, c := range []struct {
+		boundary BoundaryPolicy
+		expected []float64
+	}{
+		{Replicate, []float64{0.5, 0.8, 1, 1, 1, 1, 1, 1, 0.8, 0.5}},
+		{Reflect, []float64{0, 0.6, 1, 1, 1, 1, 1, 1, 0.6, 0}},
+		{Zero, []float64{0.5, 0.8, 1, 1, 1, 1, 1, 1, -1, -2.2}},
+	} {
+		app := dsp.NewApp("delta")
+		out := app.Chain(
+			app.Add("delta", NewDeltaProc(2, 1, c.boundary)),
+			app.Add("features", frames(linear)),
+		)
+		d := streamValues(t, out)
+		if len(d) != len(linear) {
+			t.Fatalf("boundary %d: expected %d frames, got %d", c.boundary, len(linear), len(d))
+		}
+		for i := range d {
+			compareFloats(t, c.expected[i], d[i][0], "delta", 1e-12)
+		}
+	}
+
+	// Quadratic input: first order delta is 2t, second order is 2 * 2 / 2 = 2 in the interior.
+	quad := ramp(20, func(x float64) float64 { return x * x })
+	app := dsp.NewApp("delta")
+	out := app.Chain(
+		app.Add("delta", NewDeltaProc(2, 2, Replicate)),
+		app.Add("features", frames(quad)),
+	)
+	d := streamValues(t, out)
+	if len(d) != 20 || len(d[0]) != 2 {
+		t.Fatalf("expected 20 frames of size 2, got %d frames of size %d", len(d), len(d[0]))
+	}
+	for i := 4; i < 16; i++ {
+		compareFloats(t, 2*float64(i), d[i][0], "delta", 1e-9)
+		compareFloats(t, 2, d[i][1], "delta delta", 1e-9)
+	}
+
+	// Frames can be requested in any order.
+	app.Reset()
+	v, err := out.Get(19)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareSliceFloat(t, d[19], v.(*narray.NArray).Data, "last frame", 1e-12)
+
+	// Single frame.
+	for _, b := range []BoundaryPolicy{Replicate, Reflect} {
+		app = dsp.NewApp("delta")
+		out = app.Chain(
+			app.Add("delta", NewDeltaProc(2, 2, b)),
+			app.Add("features", frames([][]float64{{3, 4}})),
+		)
+		d = streamValues(t, out)
+		if len(d) != 1 {
+			t.Fatalf("expected one frame, got %d", len(d))
+		}
+		compareSliceFloat(t, []float64{0, 0, 0, 0}, d[0], "single frame", 1e-12)
+	}
+}
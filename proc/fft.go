@@ -0,0 +1,277 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package proc
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+// FFTNorm is the normalization used by the FFT transforms.
+type FFTNorm int
+
+const (
+	// NormBackward does not scale the forward transform and scales the inverse transform by 1/n.
+	NormBackward FFTNorm = iota
+	// NormOrtho scales both the forward and inverse transforms by 1/sqrt(n).
+	NormOrtho
+	// NormForward scales the forward transform by 1/n and does not scale the inverse transform.
+	NormForward
+	// NormNone does not scale the transforms.
+	NormNone
+)
+
+/*
+FFT is a plan to compute the discrete Fourier transform of complex sequences of length n.
+A plan precomputes twiddle factors and buffers so it can be reused efficiently to transform
+many frames. Sizes that are a power of two use a radix-2 algorithm, other sizes use Bluestein's
+algorithm. A plan must not be used concurrently.
+
+The forward transform is:
+
+	       n-1
+	X[k] = sum x[j] * exp(-2 pi i j k / n)
+	       j=0
+
+and the inverse transform is:
+
+	       n-1
+	x[j] = sum X[k] * exp(2 pi i j k / n)
+	       k=0
+
+scaled as specified by the FFTNorm option.
+*/
+type FFT struct {
+	n    int
+	norm FFTNorm
+
+	// Radix-2.
+	twiddle []complex128
+	bitrev  []int
+
+	// Bluestein.
+	chirp []complex128
+	bfft  []complex128
+	sub   *FFT
+	work  []complex128
+	cbuf  []complex128
+}
+
+// NewFFT creates a plan to compute transforms of size n.
+func NewFFT(n int, norm FFTNorm) (*FFT, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("FFT size must be positive, got %d", n)
+	}
+	if norm < NormBackward || norm > NormNone {
+		return nil, fmt.Errorf("unknown FFT normalization: %d", norm)
+	}
+	p := &FFT{n: n, norm: norm, cbuf: make([]complex128, n, n)}
+	if isPowerOfTwo(n) {
+		p.initRadix2()
+		return p, nil
+	}
+
+	// Bluestein: convolve with a chirp using a power of two FFT of size m >= 2n-1.
+	m := 1
+	for m < 2*n-1 {
+		m <<= 1
+	}
+	p.sub, _ = NewFFT(m, NormNone)
+	p.chirp = make([]complex128, n, n)
+	for k := 0; k < n; k++ {
+		// k^2 mod 2n keeps the argument small.
+		kk := (k * k) % (2 * n)
+		p.chirp[k] = cmplx.Exp(complex(0, -math.Pi*float64(kk)/float64(n)))
+	}
+	p.bfft = make([]complex128, m, m)
+	p.bfft[0] = cmplx.Conj(p.chirp[0])
+	for k := 1; k < n; k++ {
+		p.bfft[k] = cmplx.Conj(p.chirp[k])
+		p.bfft[m-k] = p.bfft[k]
+	}
+	p.sub.transform(p.bfft, false)
+	p.work = make([]complex128, m, m)
+	return p, nil
+}
+
+// Len returns the size of the transform.
+func (p *FFT) Len() int {
+	return p.n
+}
+
+// Forward computes the forward transform of src and stores the result in dst.
+// If dst is nil, a new slice is allocated. The dst and src slices may be the same.
+// Returns dst.
+func (p *FFT) Forward(dst, src []complex128) []complex128 {
+	return p.do(dst, src, false)
+}
+
+// Inverse computes the inverse transform of src and stores the result in dst.
+// If dst is nil, a new slice is allocated. The dst and src slices may be the same.
+// Returns dst.
+func (p *FFT) Inverse(dst, src []complex128) []complex128 {
+	return p.do(dst, src, true)
+}
+
+// ForwardReal computes the forward transform of a real sequence. Returns the
+// non-negative frequency components, that is, n/2+1 values. If len(src) < n,
+// the input is zero padded. If dst is nil, a new slice is allocated.
+func (p *FFT) ForwardReal(dst []complex128, src []float64) []complex128 {
+	if len(src) > p.n {
+		panic(fmt.Errorf("input size [%d] is larger than FFT size [%d]", len(src), p.n))
+	}
+	for i := range p.cbuf {
+		p.cbuf[i] = 0
+	}
+	for i, v := range src {
+		p.cbuf[i] = complex(v, 0)
+	}
+	p.do(p.cbuf, p.cbuf, false)
+	nb := p.n/2 + 1
+	if dst == nil {
+		dst = make([]complex128, nb, nb)
+	}
+	copy(dst, p.cbuf[:nb])
+	return dst
+}
+
+// InverseReal computes the inverse transform of the spectrum of a real sequence.
+// Param src has the n/2+1 non-negative frequency components as returned by ForwardReal.
+// If dst is nil, a new slice of size n is allocated.
+func (p *FFT) InverseReal(dst []float64, src []complex128) []float64 {
+	nb := p.n/2 + 1
+	if len(src) != nb {
+		panic(fmt.Errorf("expected %d frequency components, got %d", nb, len(src)))
+	}
+	copy(p.cbuf, src)
+	for k := nb; k < p.n; k++ {
+		p.cbuf[k] = cmplx.Conj(src[p.n-k])
+	}
+	p.do(p.cbuf, p.cbuf, true)
+	if dst == nil {
+		dst = make([]float64, p.n, p.n)
+	}
+	for i := range dst {
+		dst[i] = real(p.cbuf[i])
+	}
+	return dst
+}
+
+func (p *FFT) do(dst, src []complex128, inverse bool) []complex128 {
+	if len(src) != p.n {
+		panic(fmt.Errorf("input size [%d] does not match FFT size [%d]", len(src), p.n))
+	}
+	if dst == nil {
+		dst = make([]complex128, p.n, p.n)
+	}
+	if len(dst) != p.n {
+		panic(fmt.Errorf("output size [%d] does not match FFT size [%d]", len(dst), p.n))
+	}
+	copy(dst, src)
+	p.transform(dst, inverse)
+
+	var scale float64
+	switch {
+	case p.norm == NormOrtho:
+		scale = 1 / math.Sqrt(float64(p.n))
+	case p.norm == NormBackward && inverse, p.norm == NormForward && !inverse:
+		scale = 1 / float64(p.n)
+	default:
+		return dst
+	}
+	for i := range dst {
+		dst[i] *= complex(scale, 0)
+	}
+	return dst
+}
+
+// transform computes the unnormalized transform in place.
+func (p *FFT) transform(x []complex128, inverse bool) {
+	if inverse {
+		// Use the forward transform: ifft(x) = conj(fft(conj(x))).
+		for i := range x {
+			x[i] = cmplx.Conj(x[i])
+		}
+	}
+	if p.sub == nil {
+		p.radix2(x)
+	} else {
+		p.bluestein(x)
+	}
+	if inverse {
+		for i := range x {
+			x[i] = cmplx.Conj(x[i])
+		}
+	}
+}
+
+func (p *FFT) initRadix2() {
+	n := p.n
+	p.twiddle = make([]complex128, n/2, n/2)
+	for k := range p.twiddle {
+		p.twiddle[k] = cmplx.Exp(complex(0, -2*math.Pi*float64(k)/float64(n)))
+	}
+	p.bitrev = make([]int, n, n)
+	bits := 0
+	for 1<<uint(bits) < n {
+		bits++
+	}
+	for i := range p.bitrev {
+		r := 0
+		for b := 0; b < bits; b++ {
+			if i&(1<<uint(b)) != 0 {
+				r |= 1 << uint(bits-1-b)
+			}
+		}
+		p.bitrev[i] = r
+	}
+}
+
+// radix2 is an iterative decimation in time FFT.
+func (p *FFT) radix2(x []complex128) {
+	n := p.n
+	for i, r := range p.bitrev {
+		if i < r {
+			x[i], x[r] = x[r], x[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		half := size >> 1
+		step := n / size
+		for start := 0; start < n; start += size {
+			for k := 0; k < half; k++ {
+				t := p.twiddle[k*step] * x[start+k+half]
+				x[start+k+half] = x[start+k] - t
+				x[start+k] += t
+			}
+		}
+	}
+}
+
+// bluestein computes a DFT of arbitrary size as a convolution.
+func (p *FFT) bluestein(x []complex128) {
+	w := p.work
+	for i := range w {
+		w[i] = 0
+	}
+	for k := 0; k < p.n; k++ {
+		w[k] = x[k] * p.chirp[k]
+	}
+	p.sub.transform(w, false)
+	for i := range w {
+		w[i] *= p.bfft[i]
+	}
+	p.sub.transform(w, true)
+	scale := complex(1/float64(len(w)), 0)
+	for k := 0; k < p.n; k++ {
+		x[k] = w[k] * p.chirp[k] * scale
+	}
+}
+
+func isPowerOfTwo(n int) bool {
+	return n > 0 && n&(n-1) == 0
+}
@@ -0,0 +1,157 @@
+package proc
+
+import (
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"testing"
+)
+
+func naiveDFT(x []complex128) []complex128 {
+	n := len(x)
+	y := make([]complex128, n)
+	for k := 0; k < n; k++ {
+		for j := 0; j < n; j++ {
+			y[k] += x[j] * cmplx.Exp(complex(0, -2*math.Pi*float64(j*k)/float64(n)))
+		}
+	}
+	return y
+}
+
+func compareComplex(t *testing.T, expected, actual []complex128, message string, epsilon float64) {
+	for i := range expected {
+		if cmplx.Abs(expected[i]-actual[i]) > epsilon {
+			t.Fatalf("[%s] index %d. Expected: [%v], Got: [%v]", message, i, expected[i], actual[i])
+		}
+	}
+}
+
+func TestFFT(t *testing.T) {
+
+	r := rand.New(rand.NewSource(33))
+	for _, n := range []int{1, 2, 3, 5, 8, 12, 16, 100, 127, 256, 400} {
+		x := make([]complex128, n)
+		for i := range x {
+			x[i] = complex(r.NormFloat64(), r.NormFloat64())
+		}
+		p, err := NewFFT(n, NormBackward)
+		if err != nil {
+			t.Fatal(err)
+		}
+		y := p.Forward(nil, x)
+		compareComplex(t, naiveDFT(x), y, "forward", 1e-9*float64(n))
+		z := p.Inverse(nil, y)
+		compareComplex(t, x, z, "inverse", 1e-9)
+
+		// In place and plan reuse.
+		x2 := make([]complex128, n)
+		copy(x2, x)
+		p.Forward(x2, x2)
+		compareComplex(t, y, x2, "in place", 1e-12)
+	}
+}
+
+func TestFFTNorm(t *testing.T) {
+
+	x := []complex128{1, 2, 3, 4, 5, 6}
+	for _, norm := range []FFTNorm{NormBackward, NormOrtho, NormForward, NormNone} {
+		p, err := NewFFT(len(x), norm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		y := p.Forward(nil, x)
+		z := p.Inverse(nil, y)
+		var scale float64
+		switch norm {
+		case NormNone:
+			scale = float64(len(x))
+		default:
+			scale = 1
+		}
+		for i := range x {
+			if cmplx.Abs(x[i]*complex(scale, 0)-z[i]) > 1e-9 {
+				t.Fatalf("norm %d: expected %v, got %v", norm, x[i]*complex(scale, 0), z[i])
+			}
+		}
+		if norm == NormOrtho {
+			// Parseval.
+			var ex, ey float64
+			for i := range x {
+				ex += real(x[i] * cmplx.Conj(x[i]))
+				ey += real(y[i] * cmplx.Conj(y[i]))
+			}
+			compareFloats(t, ex, ey, "parseval", 1e-9)
+		}
+	}
+	if _, err := NewFFT(0, NormBackward); err == nil {
+		t.Fatal("expected error for size 0")
+	}
+}
+
+// Use the values in the RealFT example.
+func TestFFTRealFT(t *testing.T) {
+
+	data := make([]float64, 16, 16)
+	data[0] = 0.5
+	data[1] = 1.0
+
+	expReal := []float64{1.5, 1.4, 1.2, 0.9, 0.5, 0.1, -0.2, -0.4, -0.5, -0.4, -0.2, 0.1, 0.5, 0.9, 1.2, 1.4}
+	expImag := []float64{0.0, -0.4, -0.7, -0.9, -1.0, -0.9, -0.7, -0.4, 0.0, 0.4, 0.7, 0.9, 1.0, 0.9, 0.7, 0.4}
+
+	p, err := NewFFT(16, NormBackward)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := make([]complex128, 16)
+	for i, v := range data {
+		x[i] = complex(v, 0)
+	}
+	y := p.Forward(nil, x)
+	re := make([]float64, 16)
+	im := make([]float64, 16)
+	for i := range y {
+		re[i] = real(y[i])
+		im[i] = imag(y[i])
+	}
+	compareSliceFloat(t, expReal, re, "real", 0.05)
+	compareSliceFloat(t, expImag, im, "imag", 0.05)
+
+	// The energy must match DFTEnergy.
+	rft := make([]float64, 16)
+	copy(rft, data)
+	RealFT(rft, 16, true)
+	egy := DFTEnergy(rft)
+	half := p.ForwardReal(nil, data)
+	if len(half) != 9 {
+		t.Fatalf("expected 9 values, got %d", len(half))
+	}
+	for k := range egy {
+		e := real(half[k] * cmplx.Conj(half[k]))
+		compareFloats(t, egy[k], e, "energy", 1e-12)
+	}
+
+	// Round trip.
+	z := p.InverseReal(nil, half)
+	compareSliceFloat(t, data, z, "inverse real", 1e-12)
+
+	// Same values using Bluestein with zero padding.
+	p2, _ := NewFFT(20, NormBackward)
+	y2 := p2.ForwardReal(nil, data)
+	compareComplex(t, naiveDFT(append(x, 0, 0, 0, 0))[:11], y2, "bluestein real", 1e-9)
+}
+
+func BenchmarkFFT256(b *testing.B) {
+	p, _ := NewFFT(256, NormBackward)
+	x := make([]complex128, 256)
+	for i := 0; i < b.N; i++ {
+		p.Forward(x, x)
+	}
+}
+
+func BenchmarkFFT400(b *testing.B) {
+	p, _ := NewFFT(400, NormBackward)
+	x := make([]complex128, 400)
+	for i := 0; i < b.N; i++ {
+		p.Forward(x, x)
+	}
+}
@@ -0,0 +1,311 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package filter
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"sort"
+
+	"github.com/akualab/dsp/proc"
+)
+
+// Type is the type of frequency response.
+type Type int
+
+const (
+	// Lowpass filter. Requires one cutoff frequency.
+	Lowpass Type = iota
+	// Highpass filter. Requires one cutoff frequency.
+	Highpass
+	// Bandpass filter. Requires two cutoff frequencies.
+	Bandpass
+	// Bandstop filter. Requires two cutoff frequencies.
+	Bandstop
+)
+
+// Butterworth designs a digital Butterworth filter using the bilinear transform.
+// Param order is the order of the analog lowpass prototype. (Bandpass and bandstop filters
+// have twice the order.) Param fc has the cutoff frequencies in Hertz where the magnitude
+// response is -3 dB. The filter is returned as a cascade of second order sections.
+func Butterworth(order int, typ Type, fs float64, fc ...float64) (SOS, error) {
+	if order < 1 {
+		return nil, fmt.Errorf("filter order must be positive, got %d", order)
+	}
+	poles := make([]complex128, order, order)
+	for k := 0; k < order; k++ {
+		theta := math.Pi * float64(2*k+1+order) / float64(2*order)
+		poles[k] = cmplx.Rect(1, theta)
+	}
+	return design(poles, 1, typ, fs, fc)
+}
+
+// Chebyshev designs a digital Chebyshev type I filter using the bilinear transform.
+// Param ripple is the maximum passband ripple in dB. The magnitude response at the cutoff
+// frequencies is -ripple dB. See Butterworth for details.
+func Chebyshev(order int, ripple float64, typ Type, fs float64, fc ...float64) (SOS, error) {
+	if order < 1 {
+		return nil, fmt.Errorf("filter order must be positive, got %d", order)
+	}
+	if ripple <= 0 {
+		return nil, fmt.Errorf("ripple must be positive, got %f", ripple)
+	}
+	eps := math.Sqrt(math.Pow(10, ripple/10) - 1)
+	mu := math.Asinh(1/eps) / float64(order)
+	poles := make([]complex128, order, order)
+	for k := 0; k < order; k++ {
+		theta := math.Pi * float64(2*k+1) / float64(2*order)
+		poles[k] = complex(-math.Sinh(mu)*math.Sin(theta), math.Cosh(mu)*math.Cos(theta))
+	}
+	// For even orders the response at the reference frequency is at the bottom of the ripple.
+	gain := 1.0
+	if order%2 == 0 {
+		gain = 1 / math.Sqrt(1+eps*eps)
+	}
+	return design(poles, gain, typ, fs, fc)
+}
+
+// design transforms the analog lowpass prototype with unit cutoff frequency to a digital filter.
+// The gain is the magnitude of the response at the reference frequency. (DC for lowpass and bandstop,
+// Nyquist for highpass, and center frequency for bandpass.)
+func design(poles []complex128, gain float64, typ Type, fs float64, fc []float64) (SOS, error) {
+
+	var nfc int
+	switch typ {
+	case Lowpass, Highpass:
+		nfc = 1
+	case Bandpass, Bandstop:
+		nfc = 2
+	default:
+		return nil, fmt.Errorf("unknown filter type: %d", typ)
+	}
+	if len(fc) != nfc {
+		return nil, fmt.Errorf("expected %d cutoff frequencies, got %d", nfc, len(fc))
+	}
+	for _, f := range fc {
+		if f <= 0 || f >= fs/2 {
+			return nil, fmt.Errorf("cutoff frequency must be between 0 and fs/2, got %f", f)
+		}
+	}
+	if nfc == 2 && fc[0] >= fc[1] {
+		return nil, fmt.Errorf("bad band edges [%f, %f]", fc[0], fc[1])
+	}
+
+	// Prewarp frequencies.
+	warp := func(f float64) float64 { return 2 * fs * math.Tan(math.Pi*f/fs) }
+
+	// Analog poles and zeros. Zeros at infinity are not included.
+	var ap, az []complex128
+	var ref float64 // reference frequency in Hz
+	switch typ {
+	case Lowpass:
+		wc := warp(fc[0])
+		for _, p := range poles {
+			ap = append(ap, p*complex(wc, 0))
+		}
+	case Highpass:
+		wc := warp(fc[0])
+		for _, p := range poles {
+			ap = append(ap, complex(wc, 0)/p)
+			az = append(az, 0)
+		}
+		ref = fs / 2
+	case Bandpass, Bandstop:
+		w1, w2 := warp(fc[0]), warp(fc[1])
+		bw := complex(w2-w1, 0)
+		w0sq := complex(w1*w2, 0)
+		for _, p := range poles {
+			if typ == Bandpass {
+				p = p * bw
+			} else {
+				p = bw / p
+				az = append(az, complex(0, math.Sqrt(w1*w2)), complex(0, -math.Sqrt(w1*w2)))
+			}
+			d := cmplx.Sqrt(p*p - 4*w0sq)
+			ap = append(ap, (p+d)/2, (p-d)/2)
+		}
+		if typ == Bandpass {
+			for range poles {
+				az = append(az, 0)
+			}
+			ref = math.Atan(math.Sqrt(w1*w2)/(2*fs)) * fs / math.Pi
+		}
+	}
+
+	// Bilinear transform. Zeros at infinity map to z=-1.
+	bilinear := func(s complex128) complex128 {
+		return (complex(2*fs, 0) + s) / (complex(2*fs, 0) - s)
+	}
+	zp := make([]complex128, len(ap), len(ap))
+	zz := make([]complex128, len(ap), len(ap))
+	for i := range ap {
+		zp[i] = bilinear(ap[i])
+		zz[i] = -1
+		if i < len(az) {
+			zz[i] = bilinear(az[i])
+		}
+	}
+
+	sos := zpkToSOS(zz, zp)
+	h := cmplx.Abs(sos.Response(ref, fs))
+	sos.scale(gain / h)
+	return sos, nil
+}
+
+// zpkToSOS groups zeros and poles into second order sections with unit gain.
+func zpkToSOS(zeros, poles []complex128) SOS {
+	zeros = sortRoots(zeros)
+	poles = sortRoots(poles)
+	n := (len(poles) + 1) / 2
+	sos := make(SOS, n, n)
+	for i := 0; i < n; i++ {
+		if 2*i+1 < len(poles) {
+			sos[i] = Biquad{
+				B0: 1,
+				B1: -real(zeros[2*i] + zeros[2*i+1]),
+				B2: real(zeros[2*i] * zeros[2*i+1]),
+				A1: -real(poles[2*i] + poles[2*i+1]),
+				A2: real(poles[2*i] * poles[2*i+1]),
+			}
+		} else {
+			sos[i] = Biquad{
+				B0: 1,
+				B1: -real(zeros[2*i]),
+				A1: -real(poles[2*i]),
+			}
+		}
+	}
+	return sos
+}
+
+// sortRoots orders roots so that complex conjugates are adjacent and
+// real roots come last.
+func sortRoots(roots []complex128) []complex128 {
+	const tol = 1e-10
+	cplx := []complex128{}
+	reals := []float64{}
+	for _, r := range roots {
+		switch {
+		case imag(r) > tol:
+			cplx = append(cplx, r)
+		case imag(r) >= -tol:
+			reals = append(reals, real(r))
+		}
+	}
+	sort.Slice(cplx, func(i, j int) bool { return real(cplx[i]) < real(cplx[j]) })
+	sort.Float64s(reals)
+	r := make([]complex128, 0, len(roots))
+	for _, c := range cplx {
+		r = append(r, c, cmplx.Conj(c))
+	}
+	for _, v := range reals {
+		r = append(r, complex(v, 0))
+	}
+	return r
+}
+
+// WindowedSinc designs a linear phase FIR filter using the window method.
+// Param numTaps is the length of the filter. (Must be odd for highpass and bandstop filters.)
+// Param winType is the window type, see proc.WindowSlice. Param fc has the cutoff frequencies in Hertz.
+func WindowedSinc(numTaps int, typ Type, fs float64, winType int, fc ...float64) ([]float64, error) {
+	if numTaps < 1 {
+		return nil, fmt.Errorf("number of taps must be positive, got %d", numTaps)
+	}
+	if (typ == Highpass || typ == Bandstop) && numTaps%2 == 0 {
+		return nil, fmt.Errorf("number of taps must be odd for highpass and bandstop filters, got %d", numTaps)
+	}
+	win, err := proc.WindowSlice(winType|proc.Symmetric, numTaps)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range fc {
+		if f <= 0 || f >= fs/2 {
+			return nil, fmt.Errorf("cutoff frequency must be between 0 and fs/2, got %f", f)
+		}
+	}
+
+	// lowpass returns the windowed ideal lowpass response with cutoff f.
+	lowpass := func(f float64) []float64 {
+		h := make([]float64, numTaps, numTaps)
+		m := float64(numTaps-1) / 2
+		w := 2 * f / fs
+		for i := range h {
+			x := float64(i) - m
+			if x == 0 {
+				h[i] = w
+			} else {
+				h[i] = math.Sin(math.Pi*w*x) / (math.Pi * x)
+			}
+			h[i] *= win[i]
+		}
+		return h
+	}
+	// invert returns the spectral inversion of h.
+	invert := func(h []float64) []float64 {
+		for i := range h {
+			h[i] = -h[i]
+		}
+		h[numTaps/2]++
+		return h
+	}
+
+	var h []float64
+	var ref float64
+	switch typ {
+	case Lowpass, Highpass:
+		if len(fc) != 1 {
+			return nil, fmt.Errorf("expected 1 cutoff frequency, got %d", len(fc))
+		}
+		h = lowpass(fc[0])
+		normalize(h, 0, fs)
+		if typ == Highpass {
+			h = invert(h)
+			ref = fs / 2
+		}
+	case Bandpass, Bandstop:
+		if len(fc) != 2 || fc[0] >= fc[1] {
+			return nil, fmt.Errorf("expected 2 cutoff frequencies f1 < f2, got %v", fc)
+		}
+		h1 := lowpass(fc[0])
+		h = lowpass(fc[1])
+		normalize(h1, 0, fs)
+		normalize(h, 0, fs)
+		for i := range h {
+			h[i] -= h1[i]
+		}
+		ref = (fc[0] + fc[1]) / 2
+		if typ == Bandstop {
+			h = invert(h)
+			ref = 0
+		}
+	default:
+		return nil, fmt.Errorf("unknown filter type: %d", typ)
+	}
+	normalize(h, ref, fs)
+	return h, nil
+}
+
+// normalize scales the FIR filter h so the magnitude response at frequency f is one.
+func normalize(h []float64, f, fs float64) {
+	g := cmplx.Abs(FIRResponse(h, f, fs))
+	if g == 0 {
+		return
+	}
+	for i := range h {
+		h[i] /= g
+	}
+}
+
+// FIRResponse returns the frequency response of FIR filter h at frequency f in Hertz.
+func FIRResponse(h []float64, f, fs float64) complex128 {
+	w := 2 * math.Pi * f / fs
+	var sum complex128
+	for i, v := range h {
+		sum += complex(v, 0) * cmplx.Exp(complex(0, -w*float64(i)))
+	}
+	return sum
+}
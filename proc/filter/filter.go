@@ -0,0 +1,168 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package filter provides time-domain filtering processors and filter design functions.
+
+The processors filter each input vector independently starting with zero state. To filter
+a waveform, connect the processor to a source that returns the entire waveform for index zero
+(for example, wav.SourceProc with frameSize=0) and connect the output to proc.WindowProc.
+For example, to apply a highpass filter and pre-emphasis before windowing:
+
+	hp, err := filter.Butterworth(4, filter.Highpass, 16000, 60)
+	...
+	app.Chain(
+	  app.Add("windowed", proc.NewWindowProc(160, 400, proc.Hamming, true)),
+	  app.Add("pre-emphasis", filter.PreEmphasis(0.97)),
+	  app.Add("highpass", filter.IIR(hp)),
+	  app.Add("wav", source),
+	)
+
+Connected to a frame source, the same processors filter frames independently.
+*/
+package filter
+
+import (
+	"math"
+	"math/cmplx"
+
+	"github.com/akualab/dsp"
+	narray "github.com/akualab/narray/na64"
+)
+
+const defaultBufSize = 1000
+
+// Biquad is a second order section. The transfer function is:
+//
+//	       B0 + B1 z^-1 + B2 z^-2
+//	H(z) = ----------------------
+//	        1 + A1 z^-1 + A2 z^-2
+type Biquad struct {
+	B0, B1, B2 float64
+	A1, A2     float64
+}
+
+// Response returns the frequency response at frequency f in Hertz.
+func (bq Biquad) Response(f, fs float64) complex128 {
+	z1 := cmplx.Exp(complex(0, -2*math.Pi*f/fs))
+	z2 := z1 * z1
+	num := complex(bq.B0, 0) + complex(bq.B1, 0)*z1 + complex(bq.B2, 0)*z2
+	den := 1 + complex(bq.A1, 0)*z1 + complex(bq.A2, 0)*z2
+	return num / den
+}
+
+// SOS is a cascade of second order sections.
+type SOS []Biquad
+
+// Response returns the frequency response at frequency f in Hertz.
+func (s SOS) Response(f, fs float64) complex128 {
+	h := complex(1, 0)
+	for _, bq := range s {
+		h *= bq.Response(f, fs)
+	}
+	return h
+}
+
+// Filter returns the filtered signal. The sections are implemented using the
+// transposed direct form II structure.
+func (s SOS) Filter(x []float64) []float64 {
+	y := make([]float64, len(x), len(x))
+	copy(y, x)
+	for _, bq := range s {
+		var s1, s2 float64
+		for i, in := range y {
+			out := bq.B0*in + s1
+			s1 = bq.B1*in - bq.A1*out + s2
+			s2 = bq.B2*in - bq.A2*out
+			y[i] = out
+		}
+	}
+	return y
+}
+
+// scale multiplies the gain of the cascade by g.
+func (s SOS) scale(g float64) {
+	if len(s) == 0 {
+		return
+	}
+	s[0].B0 *= g
+	s[0].B1 *= g
+	s[0].B2 *= g
+}
+
+// FilterFIR returns the signal filtered using the FIR filter h. The output has the same
+// length as the input.
+//
+//	       M-1
+//	y[n] = sum h[k] * x[n-k]
+//	       k=0
+func FilterFIR(h, x []float64) []float64 {
+	y := make([]float64, len(x), len(x))
+	for n := range y {
+		var sum float64
+		for k := 0; k < len(h) && k <= n; k++ {
+			sum += h[k] * x[n-k]
+		}
+		y[n] = sum
+	}
+	return y
+}
+
+// filterProc returns a processor that applies f to the input vector.
+func filterProc(f func(x []float64) []float64) dsp.Processer {
+	return dsp.NewProc(defaultBufSize, func(idx int, in ...dsp.Processer) (dsp.Value, error) {
+		vec, err := dsp.Processers(in).Get(idx)
+		if err != nil {
+			return nil, err
+		}
+		y := f(vec.(*narray.NArray).Data)
+		return narray.NewArray(y, len(y)), nil
+	})
+}
+
+// FIR returns a processor that filters the input using the FIR filter h.
+func FIR(h []float64) dsp.Processer {
+	return filterProc(func(x []float64) []float64 {
+		return FilterFIR(h, x)
+	})
+}
+
+// IIR returns a processor that filters the input using a cascade of second order sections.
+func IIR(sos SOS) dsp.Processer {
+	return filterProc(sos.Filter)
+}
+
+// PreEmphasis returns a pre-emphasis processor.
+//
+//	y[0] = x[0]
+//	y[n] = x[n] - alpha * x[n-1]
+func PreEmphasis(alpha float64) dsp.Processer {
+	return filterProc(func(x []float64) []float64 {
+		y := make([]float64, len(x), len(x))
+		for n := len(x) - 1; n > 0; n-- {
+			y[n] = x[n] - alpha*x[n-1]
+		}
+		if len(x) > 0 {
+			y[0] = x[0]
+		}
+		return y
+	})
+}
+
+// DCRemoval returns a processor that removes the DC component using a first order highpass filter.
+// Param r is the pole of the filter; a value close to one, such as 0.995, gives a very narrow notch at DC.
+//
+//	y[n] = x[n] - x[n-1] + r * y[n-1]
+func DCRemoval(r float64) dsp.Processer {
+	return filterProc(func(x []float64) []float64 {
+		y := make([]float64, len(x), len(x))
+		var xm1, ym1 float64
+		for n, v := range x {
+			y[n] = v - xm1 + r*ym1
+			xm1, ym1 = v, y[n]
+		}
+		return y
+	})
+}
@@ -0,0 +1,209 @@
+package filter
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"github.com/akualab/dsp"
+	"github.com/akualab/dsp/proc"
+	"github.com/akualab/dsp/proc/gen"
+	narray "github.com/akualab/narray/na64"
+)
+
+const fs = 8000.0
+
+func db(h complex128) float64 {
+	return 20 * math.Log10(cmplx.Abs(h))
+}
+
+func checkDB(t *testing.T, name string, h complex128, exp, tol float64) {
+	if math.Abs(db(h)-exp) > tol {
+		t.Fatalf("%s: expected %.2f dB, got %.2f dB", name, exp, db(h))
+	}
+}
+
+func TestButterworth(t *testing.T) {
+
+	lp, err := Butterworth(5, Lowpass, fs, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lp) != 3 {
+		t.Fatalf("expected 3 sections, got %d", len(lp))
+	}
+	checkDB(t, "lp dc", lp.Response(0, fs), 0, 1e-9)
+	checkDB(t, "lp fc", lp.Response(1000, fs), -3.0103, 1e-3)
+	if db(lp.Response(3000, fs)) > -40 {
+		t.Fatalf("expected attenuation at 3 kHz, got %.2f dB", db(lp.Response(3000, fs)))
+	}
+
+	hp, err := Butterworth(4, Highpass, fs, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkDB(t, "hp nyquist", hp.Response(fs/2, fs), 0, 1e-9)
+	checkDB(t, "hp fc", hp.Response(200, fs), -3.0103, 1e-3)
+	if cmplx.Abs(hp.Response(0, fs)) > 1e-9 {
+		t.Fatal("expected zero response at DC")
+	}
+
+	bp, err := Butterworth(3, Bandpass, fs, 500, 1500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkDB(t, "bp f1", bp.Response(500, fs), -3.0103, 1e-3)
+	checkDB(t, "bp f2", bp.Response(1500, fs), -3.0103, 1e-3)
+	checkDB(t, "bp center", bp.Response(math.Sqrt(500*1500), fs), 0, 0.2)
+
+	bs, err := Butterworth(2, Bandstop, fs, 900, 1100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkDB(t, "bs dc", bs.Response(0, fs), 0, 1e-9)
+	checkDB(t, "bs f1", bs.Response(900, fs), -3.0103, 1e-3)
+	if db(bs.Response(995, fs)) > -30 {
+		t.Fatalf("expected notch at 1 kHz, got %.2f dB", db(bs.Response(995, fs)))
+	}
+
+	if _, err := Butterworth(2, Lowpass, fs, 5000); err == nil {
+		t.Fatal("expected error for cutoff above Nyquist")
+	}
+	if _, err := Butterworth(2, Bandpass, fs, 500); err == nil {
+		t.Fatal("expected error for missing band edge")
+	}
+}
+
+func TestChebyshev(t *testing.T) {
+
+	for _, order := range []int{3, 4} {
+		lp, err := Chebyshev(order, 1, Lowpass, fs, 1000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkDB(t, "cheby fc", lp.Response(1000, fs), -1, 1e-3)
+		for f := 0.0; f < 1000; f += 10 {
+			g := db(lp.Response(f, fs))
+			if g > 1e-6 || g < -1-1e-6 {
+				t.Fatalf("order %d: passband ripple out of range at %f Hz: %.3f dB", order, f, g)
+			}
+		}
+	}
+}
+
+func TestWindowedSinc(t *testing.T) {
+
+	h, err := WindowedSinc(101, Lowpass, fs, proc.Hamming, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range h {
+		if math.Abs(h[i]-h[len(h)-1-i]) > 1e-12 {
+			t.Fatal("expected linear phase (symmetric) filter")
+		}
+	}
+	checkDB(t, "fir dc", FIRResponse(h, 0, fs), 0, 1e-9)
+	checkDB(t, "fir fc", FIRResponse(h, 1000, fs), -6, 0.2)
+	if db(FIRResponse(h, 1500, fs)) > -40 {
+		t.Fatal("expected stopband attenuation")
+	}
+
+	hp, err := WindowedSinc(101, Highpass, fs, proc.Hamming, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkDB(t, "fir hp nyquist", FIRResponse(hp, fs/2, fs), 0, 1e-9)
+
+	bp, err := WindowedSinc(101, Bandpass, fs, proc.Blackman, 1000, 2000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkDB(t, "fir bp center", FIRResponse(bp, 1500, fs), 0, 1e-9)
+	if db(FIRResponse(bp, 200, fs)) > -40 {
+		t.Fatal("expected stopband attenuation")
+	}
+
+	if _, err := WindowedSinc(100, Highpass, fs, proc.Hamming, 1000); err == nil {
+		t.Fatal("expected error for even number of taps")
+	}
+}
+
+func run(t *testing.T, p dsp.Processer, x []float64) []float64 {
+	app := dsp.NewApp("filter")
+	out := app.Chain(
+		app.Add("filter", p),
+		app.Add("source", gen.NewSource("x", x)),
+	)
+	v, err := out.Get(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v.(*narray.NArray).Data
+}
+
+func TestIIRProc(t *testing.T) {
+
+	sos, err := Butterworth(4, Lowpass, fs, 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := gen.Add(gen.Sine(4000, fs, 100, 1, 0), gen.Sine(4000, fs, 3000, 1, 0))
+	y := run(t, IIR(sos), x)
+
+	// After the transient, only the 100 Hz component remains.
+	var egy float64
+	for i := 2000; i < len(y); i++ {
+		egy += y[i] * y[i]
+	}
+	if rms := math.Sqrt(egy / 2000); math.Abs(rms-1/math.Sqrt2) > 0.01 {
+		t.Fatalf("expected rms %f, got %f", 1/math.Sqrt2, rms)
+	}
+
+	// Impulse response of a single section.
+	bq := SOS{{B0: 1, B1: 0.5, A1: -0.5}}
+	y = run(t, IIR(bq), gen.Impulse(4, 0, 1))
+	exp := []float64{1, 1, 0.5, 0.25}
+	for i := range exp {
+		if math.Abs(exp[i]-y[i]) > 1e-12 {
+			t.Fatalf("sample %d - expected %f, got %f", i, exp[i], y[i])
+		}
+	}
+}
+
+func TestFIRProc(t *testing.T) {
+
+	y := run(t, FIR([]float64{0.5, 0.5}), []float64{2, 4, 6, 8})
+	exp := []float64{1, 3, 5, 7}
+	for i := range exp {
+		if exp[i] != y[i] {
+			t.Fatalf("sample %d - expected %f, got %f", i, exp[i], y[i])
+		}
+	}
+}
+
+func TestPreEmphasis(t *testing.T) {
+
+	y := run(t, PreEmphasis(0.97), []float64{1, 2, 3})
+	exp := []float64{1, 2 - 0.97, 3 - 2*0.97}
+	for i := range exp {
+		if math.Abs(exp[i]-y[i]) > 1e-12 {
+			t.Fatalf("sample %d - expected %f, got %f", i, exp[i], y[i])
+		}
+	}
+}
+
+func TestDCRemoval(t *testing.T) {
+
+	x := gen.Sine(16000, fs, 440, 1, 0)
+	for i := range x {
+		x[i] += 3
+	}
+	y := run(t, DCRemoval(0.995), x)
+	var sum float64
+	for _, v := range y[8000:] {
+		sum += v
+	}
+	if mean := sum / 8000; math.Abs(mean) > 0.01 {
+		t.Fatalf("expected zero mean, got %f", mean)
+	}
+}
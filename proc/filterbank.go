@@ -0,0 +1,173 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package proc
+
+import (
+	"fmt"
+	"math"
+)
+
+// FreqScale converts frequencies between Hertz and a perceptual frequency scale.
+type FreqScale interface {
+	// Scale converts a frequency in Hertz to the scale.
+	Scale(hz float64) float64
+	// Hz converts a value in the scale to Hertz.
+	Hz(x float64) float64
+}
+
+var (
+	// MelHTK is the mel scale as defined in HTK and Kaldi.
+	//  mel = 2595 * log10(1 + f/700)
+	MelHTK FreqScale = melHTK{}
+	// MelSlaney is the mel scale used in Slaney's Auditory Toolbox and librosa. It is
+	// linear below 1 kHz and logarithmic above.
+	MelSlaney FreqScale = melSlaney{}
+	// Bark is the Bark scale using Traunmuller's formula.
+	//  z = 26.81 * f / (1960 + f) - 0.53
+	Bark FreqScale = bark{}
+	// ERB is the equivalent rectangular bandwidth rate scale of Glasberg and Moore.
+	//  erb = 21.4 * log10(1 + 0.00437 * f)
+	ERB FreqScale = erb{}
+)
+
+type melHTK struct{}
+
+func (melHTK) Scale(hz float64) float64 { return 2595 * math.Log10(1+hz/700) }
+func (melHTK) Hz(x float64) float64     { return 700 * (math.Pow(10, x/2595) - 1) }
+
+const (
+	slaneyStep    = 200.0 / 3 // Hz per mel in the linear region.
+	slaneyMinLog  = 1000.0    // Start of the log region in Hz.
+	slaneyMinMel  = slaneyMinLog / slaneyStep
+	slaneyLogStep = 0.06875177742094912 // log(6.4) / 27
+)
+
+type melSlaney struct{}
+
+func (melSlaney) Scale(hz float64) float64 {
+	if hz < slaneyMinLog {
+		return hz / slaneyStep
+	}
+	return slaneyMinMel + math.Log(hz/slaneyMinLog)/slaneyLogStep
+}
+
+func (melSlaney) Hz(x float64) float64 {
+	if x < slaneyMinMel {
+		return x * slaneyStep
+	}
+	return slaneyMinLog * math.Exp(slaneyLogStep*(x-slaneyMinMel))
+}
+
+type bark struct{}
+
+func (bark) Scale(hz float64) float64 { return 26.81*hz/(1960+hz) - 0.53 }
+func (bark) Hz(x float64) float64     { return 1960 * (x + 0.53) / (26.28 - x) }
+
+type erb struct{}
+
+func (erb) Scale(hz float64) float64 { return 21.4 * math.Log10(1+0.00437*hz) }
+func (erb) Hz(x float64) float64     { return (math.Pow(10, x/21.4) - 1) / 0.00437 }
+
+// FilterbankFlag sets options for GenerateScaleFilterbank.
+type FilterbankFlag int
+
+const (
+	// AreaNormalize scales each filter by 2/(f_high - f_low) so all filters have the same
+	// area in Hertz. (The "slaney" normalization in librosa.)
+	AreaNormalize FilterbankFlag = 1 << iota
+	// HzTriangles interpolates the filter weights linearly in Hertz. (As in librosa.)
+	// By default, weights are interpolated linearly on the frequency scale. (As in HTK and Kaldi.)
+	HzTriangles
+)
+
+/*
+GenerateScaleFilterbank generates overlapping triangular filters equally spaced on a
+perceptual frequency scale. The result can be used with the Filterbank processor.
+
+Param n is the size of the spectrum vector where element k corresponds to frequency k*fs/(2n).
+(For example, the output of SpectralEnergy(logSize) has n=2^logSize values.) Param nf is the
+number of filters. The filter edges are nf+2 points equally spaced on the scale between minFreq
+and maxFreq, filter i starts at point i, peaks at point i+1 and ends at point i+2.
+
+Returns the index of the first non-zero weight and the weights for each filter. Returns an error
+if the parameters are out of range or if a filter does not include any spectrum values. (Reduce
+the number of filters or increase the FFT size.)
+*/
+func GenerateScaleFilterbank(n, nf int, fs, minFreq, maxFreq float64, scale FreqScale, flags FilterbankFlag) ([]int, [][]float64, error) {
+
+	if n < 1 || nf < 1 {
+		return nil, nil, fmt.Errorf("spectrum size and number of filters must be positive, got n:%d, nf:%d", n, nf)
+	}
+	if minFreq < 0 || maxFreq <= minFreq || maxFreq > fs/2 {
+		return nil, nil, fmt.Errorf("bad freq combination, got fs:%f, min:%f, max:%f", fs, minFreq, maxFreq)
+	}
+
+	// Filter edges on the scale and in Hertz.
+	lo := scale.Scale(minFreq)
+	hi := scale.Scale(maxFreq)
+	edges := make([]float64, nf+2, nf+2)
+	hz := make([]float64, nf+2, nf+2)
+	for i := range edges {
+		edges[i] = lo + float64(i)*(hi-lo)/float64(nf+1)
+		hz[i] = scale.Hz(edges[i])
+	}
+
+	indices := make([]int, nf, nf)
+	filters := make([][]float64, nf, nf)
+	for i := 0; i < nf; i++ {
+		start := -1
+		weights := []float64{}
+		for k := 0; k < n; k++ {
+			f := float64(k) * fs / float64(2*n)
+			if f <= hz[i] || f >= hz[i+2] {
+				if start >= 0 {
+					break
+				}
+				continue
+			}
+			var w float64
+			if flags&HzTriangles != 0 {
+				if f <= hz[i+1] {
+					w = (f - hz[i]) / (hz[i+1] - hz[i])
+				} else {
+					w = (hz[i+2] - f) / (hz[i+2] - hz[i+1])
+				}
+			} else {
+				x := scale.Scale(f)
+				if x <= edges[i+1] {
+					w = (x - edges[i]) / (edges[i+1] - edges[i])
+				} else {
+					w = (edges[i+2] - x) / (edges[i+2] - edges[i+1])
+				}
+			}
+			if flags&AreaNormalize != 0 {
+				w *= 2 / (hz[i+2] - hz[i])
+			}
+			if start < 0 {
+				start = k
+			}
+			weights = append(weights, w)
+		}
+		if start < 0 {
+			return nil, nil, fmt.Errorf("filter %d between %.1f Hz and %.1f Hz has no spectrum values, reduce the number of filters or increase the FFT size", i, hz[i], hz[i+2])
+		}
+		indices[i] = start
+		filters[i] = weights
+	}
+	return indices, filters, nil
+}
+
+// FilterCenters returns the center frequencies in Hertz of the filters generated by
+// GenerateScaleFilterbank using the same parameters.
+func FilterCenters(nf int, minFreq, maxFreq float64, scale FreqScale) []float64 {
+	lo := scale.Scale(minFreq)
+	hi := scale.Scale(maxFreq)
+	c := make([]float64, nf, nf)
+	for i := range c {
+		c[i] = scale.Hz(lo + float64(i+1)*(hi-lo)/float64(nf+1))
+	}
+	return c
+}
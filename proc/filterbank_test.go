@@ -0,0 +1,81 @@
+package proc
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFreqScales(t *testing.T) {
+
+	compareFloats(t, 1000, MelHTK.Scale(1000), "mel htk", 0.05)
+	compareFloats(t, 15, MelSlaney.Scale(1000), "mel slaney", 1e-9)
+	compareFloats(t, 3, MelSlaney.Scale(200), "mel slaney linear", 1e-9)
+	compareFloats(t, 8.53, Bark.Scale(1000), "bark", 0.01)
+	compareFloats(t, 15.62, ERB.Scale(1000), "erb", 0.01)
+	for _, s := range []FreqScale{MelHTK, MelSlaney, Bark, ERB} {
+		for _, f := range []float64{0, 100, 999, 1000, 4000, 7999} {
+			compareFloats(t, f, s.Hz(s.Scale(f)), "round trip", 1e-6)
+		}
+	}
+}
+
+func TestGenerateScaleFilterbank(t *testing.T) {
+
+	n := 256
+	fs := 16000.0
+	indices, coeff, err := GenerateScaleFilterbank(n, 23, fs, 20, 8000, MelHTK, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(indices) != 23 || len(coeff) != 23 {
+		t.Fatalf("expected 23 filters, got %d", len(indices))
+	}
+
+	// Triangles interpolated on the scale add up to one between the first and last center.
+	centers := FilterCenters(23, 20, 8000, MelHTK)
+	sum := make([]float64, n)
+	for i := range indices {
+		for k, w := range coeff[i] {
+			if w <= 0 || w > 1 {
+				t.Fatalf("filter %d: weight out of range: %f", i, w)
+			}
+			sum[indices[i]+k] += w
+		}
+	}
+	for k := range sum {
+		f := float64(k) * fs / float64(2*n)
+		if f > centers[0] && f < centers[22] {
+			compareFloats(t, 1, sum[k], "sum of weights", 1e-9)
+		}
+	}
+
+	// Area normalization.
+	indices, coeff, err = GenerateScaleFilterbank(n, 40, fs, 0, 8000, MelSlaney, AreaNormalize|HzTriangles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	df := fs / float64(2*n)
+	for i := range coeff {
+		var area float64
+		for _, w := range coeff[i] {
+			area += w * df
+		}
+		// The area of a triangle with height 2/width is one.
+		if math.Abs(area-1) > 0.25 {
+			t.Fatalf("filter %d: expected area close to one, got %f", i, area)
+		}
+	}
+
+	for _, s := range []FreqScale{Bark, ERB} {
+		if _, _, err := GenerateScaleFilterbank(n, 20, fs, 50, 7000, s, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, _, err := GenerateScaleFilterbank(64, 60, fs, 0, 8000, MelHTK, 0); err == nil {
+		t.Fatal("expected error for too many filters")
+	}
+	if _, _, err := GenerateScaleFilterbank(n, 20, fs, 100, 9000, MelHTK, 0); err == nil {
+		t.Fatal("expected error for max frequency above Nyquist")
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package proc
+
+import (
+	"math"
+
+	"github.com/akualab/dsp"
+	narray "github.com/akualab/narray/na64"
+)
+
+// RemoveDC subtracts the mean value of the input frame from each element.
+func RemoveDC() dsp.Processer {
+	return dsp.NewProc(defaultBufSize, func(idx int, in ...dsp.Processer) (dsp.Value, error) {
+		vec, err := dsp.Processers(in).Get(idx)
+		if err != nil {
+			return nil, err
+		}
+		x := vec.(*narray.NArray).Data
+		y := narray.New(len(x))
+		if len(x) == 0 {
+			return y, nil
+		}
+		var mean float64
+		for _, v := range x {
+			mean += v
+		}
+		mean /= float64(len(x))
+		for i, v := range x {
+			y.Data[i] = v - mean
+		}
+		return y, nil
+	})
+}
+
+// FramePreEmphasis applies pre-emphasis to each input frame independently. The first sample
+// is scaled so that the frame does not depend on the previous frame. (As in HTK and Kaldi.)
+//
+//	y[0] = (1 - alpha) * x[0]
+//	y[n] = x[n] - alpha * x[n-1]
+func FramePreEmphasis(alpha float64) dsp.Processer {
+	return dsp.NewProc(defaultBufSize, func(idx int, in ...dsp.Processer) (dsp.Value, error) {
+		vec, err := dsp.Processers(in).Get(idx)
+		if err != nil {
+			return nil, err
+		}
+		x := vec.(*narray.NArray).Data
+		y := narray.New(len(x))
+		for n := len(x) - 1; n > 0; n-- {
+			y.Data[n] = x[n] - alpha*x[n-1]
+		}
+		if len(x) > 0 {
+			y.Data[0] = (1 - alpha) * x[0]
+		}
+		return y, nil
+	})
+}
+
+// ApplyWindow multiplies the input frame by a window of the same size. The window type
+// can be combined with Symmetric. (See WindowSlice.)
+func ApplyWindow(winType int) dsp.Processer {
+	var w []float64
+	return dsp.NewProc(defaultBufSize, func(idx int, in ...dsp.Processer) (dsp.Value, error) {
+		vec, err := dsp.Processers(in).Get(idx)
+		if err != nil {
+			return nil, err
+		}
+		x := vec.(*narray.NArray).Data
+		if len(w) != len(x) {
+			w, err = WindowSlice(winType, len(x))
+			if err != nil {
+				return nil, err
+			}
+		}
+		y := narray.New(len(x))
+		for i, v := range x {
+			y.Data[i] = v * w[i]
+		}
+		return y, nil
+	})
+}
+
+// LogEnergy returns the natural logarithm of the energy of the input frame. Energy values
+// less than floor are replaced with floor.
+func LogEnergy(floor float64) dsp.Processer {
+	return dsp.NewProc(defaultBufSize, func(idx int, in ...dsp.Processer) (dsp.Value, error) {
+		vec, err := dsp.Processers(in).Get(idx)
+		if err != nil {
+			return nil, err
+		}
+		x := vec.(*narray.NArray)
+		egy := narray.New(1)
+		egy.Set(math.Log(math.Max(narray.Dot(x, x), floor)), 0)
+		return egy, nil
+	})
+}
+
+// Pad extends the input vector with left and right values using the boundary policy.
+// The input must return all source data on index zero. For example, use Pad(n/2, n/2, Reflect)
+// followed by a non-centered WindowProc of size n to center frame i on sample i*step. (As
+// librosa does with center=True.) Reflected indices that are out of bounds are replicated.
+func Pad(left, right int, policy BoundaryPolicy) dsp.Processer {
+	return dsp.NewProc(defaultBufSize, func(idx int, in ...dsp.Processer) (dsp.Value, error) {
+		if idx > 0 {
+			return nil, dsp.ErrOOB
+		}
+		vec, err := dsp.Processers(in).Get(idx)
+		if err != nil {
+			return nil, err
+		}
+		x := vec.(*narray.NArray).Data
+		n := len(x)
+		y := narray.New(left + n + right)
+		if n == 0 {
+			return y, nil
+		}
+		for i := range y.Data {
+			j := i - left
+			if j < 0 || j >= n {
+				switch policy {
+				case Zero:
+					continue
+				case Reflect:
+					if j < 0 {
+						j = -j
+					} else {
+						j = 2*(n-1) - j
+					}
+				}
+				if j < 0 {
+					j = 0
+				}
+				if j >= n {
+					j = n - 1
+				}
+			}
+			y.Data[i] = x[j]
+		}
+		return y, nil
+	})
+}
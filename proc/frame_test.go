@@ -0,0 +1,73 @@
+package proc
+
+import (
+	"math"
+	"testing"
+
+	"github.com/akualab/dsp"
+	narray "github.com/akualab/narray/na64"
+)
+
+func TestFrameProcs(t *testing.T) {
+
+	x := [][]float64{{1, 2, 3, 6}}
+	for _, c := range []struct {
+		name     string
+		p        dsp.Processer
+		expected []float64
+	}{
+		{"remove dc", RemoveDC(), []float64{-2, -1, 0, 3}},
+		{"pre-emphasis", FramePreEmphasis(0.5), []float64{0.5, 1.5, 2, 4.5}},
+		{"window", ApplyWindow(Hanning | Symmetric), []float64{0, 1.5, 2.25, 0}},
+		{"log energy", LogEnergy(1e-10), []float64{math.Log(50)}},
+	} {
+		app := dsp.NewApp("frame")
+		out := app.Chain(
+			app.Add(c.name, c.p),
+			app.Add("frames", frames(x)),
+		)
+		v, err := out.Get(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		compareSliceFloat(t, c.expected, v.(*narray.NArray).Data, c.name, 1e-12)
+	}
+
+	app := dsp.NewApp("floor")
+	out := app.Chain(
+		app.Add("log energy", LogEnergy(1e-10)),
+		app.Add("frames", frames([][]float64{{0, 0}})),
+	)
+	v, err := out.Get(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareFloats(t, math.Log(1e-10), v.(*narray.NArray).Data[0], "floor", 1e-12)
+}
+
+func TestPad(t *testing.T) {
+
+	x := []float64{1, 2, 3, 4}
+	for _, c := range []struct {
+		policy   BoundaryPolicy
+		expected []float64
+	}{
+		{Reflect, []float64{3, 2, 1, 2, 3, 4, 3, 2}},
+		{Replicate, []float64{1, 1, 1, 2, 3, 4, 4, 4}},
+		{Zero, []float64{0, 0, 1, 2, 3, 4, 0, 0}},
+	} {
+		app := dsp.NewApp("pad")
+		out := app.Chain(
+			app.Add("pad", Pad(2, 2, c.policy)),
+			app.Add("wav", wavSP(x)),
+		)
+		v, err := out.Get(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		compareSliceFloat(t, c.expected, v.(*narray.NArray).Data, "pad", 1e-15)
+		if _, err := out.Get(1); err != dsp.ErrOOB {
+			t.Fatalf("expected ErrOOB, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package gen_test
+
+import (
+	"fmt"
+
+	"github.com/akualab/dsp"
+	"github.com/akualab/dsp/proc"
+	"github.com/akualab/dsp/proc/gen"
+	narray "github.com/akualab/narray/na64"
+)
+
+// Find the frequency of the strongest spectral component of a noisy tone.
+func ExampleNewSource() {
+
+	fs := 8000.0
+	tone := gen.Sine(8000, fs, 1000, 1, 0)
+	noisy := gen.Mix(tone, gen.WhiteNoise(8000, 1, 99), 10)
+
+	app := dsp.NewApp("tone")
+	out := app.Chain(
+		app.Add("spectrum", proc.SpectralEnergy(8)),
+		app.Add("window", proc.NewWindowProc(80, 256, proc.Hamming, false)),
+		app.Add("source", gen.NewSource("tone", noisy, gen.Fs(fs))),
+	)
+	v, err := out.Get(10)
+	if err != nil {
+		panic(err)
+	}
+	egy := v.(*narray.NArray).Data
+	var k int
+	for i := range egy {
+		if egy[i] > egy[k] {
+			k = i
+		}
+	}
+	fmt.Println(float64(k) * fs / 512)
+	// Output:
+	// 1000
+}
@@ -0,0 +1,237 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package gen provides source processors that generate synthetic signals.
+
+Signals are generated as a slice of samples using functions such as Sine, Chirp,
+WhiteNoise or DTMF. A Source processor makes the samples available to an app
+using the same interface as wav.SourceProc. For example:
+
+	samples := gen.Mix(gen.Sine(8000, 8000, 440, 1, 0), gen.WhiteNoise(8000, 1, 99), 10)
+	src := gen.NewSource("tone", samples, gen.Fs(8000))
+
+Random signals are generated using a seeded random number generator so results are
+reproducible.
+*/
+package gen
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// Sine returns n samples of a sinusoid.
+//
+//	x[i] = amp * sin(2 pi freq i / fs + phase)
+func Sine(n int, fs, freq, amp, phase float64) []float64 {
+	x := make([]float64, n, n)
+	w := 2 * math.Pi * freq / fs
+	for i := range x {
+		x[i] = amp * math.Sin(w*float64(i)+phase)
+	}
+	return x
+}
+
+// Chirp returns n samples of a linear chirp whose instantaneous frequency
+// goes from f0 at sample 0 to f1 at sample n.
+func Chirp(n int, fs, f0, f1, amp float64) []float64 {
+	x := make([]float64, n, n)
+	dur := float64(n) / fs
+	k := (f1 - f0) / dur // chirp rate in Hz/s
+	for i := range x {
+		t := float64(i) / fs
+		x[i] = amp * math.Sin(2*math.Pi*(f0*t+0.5*k*t*t))
+	}
+	return x
+}
+
+// Square returns n samples of a square wave with values amp and -amp.
+func Square(n int, fs, freq, amp float64) []float64 {
+	x := make([]float64, n, n)
+	period := fs / freq
+	for i := range x {
+		if math.Mod(float64(i), period) < period/2 {
+			x[i] = amp
+		} else {
+			x[i] = -amp
+		}
+	}
+	return x
+}
+
+// Impulse returns n samples with value amp at sample pos and zero everywhere else.
+func Impulse(n, pos int, amp float64) []float64 {
+	x := make([]float64, n, n)
+	if pos >= 0 && pos < n {
+		x[pos] = amp
+	}
+	return x
+}
+
+// ImpulseTrain returns n samples with value amp every period samples starting at sample zero.
+// Panics if period is not positive.
+func ImpulseTrain(n, period int, amp float64) []float64 {
+	if period < 1 {
+		panic(fmt.Errorf("impulse train period must be positive, got %d", period))
+	}
+	x := make([]float64, n, n)
+	for i := 0; i < n; i += period {
+		x[i] = amp
+	}
+	return x
+}
+
+// WhiteNoise returns n samples of Gaussian white noise with standard deviation sd.
+func WhiteNoise(n int, sd float64, seed int64) []float64 {
+	r := rand.New(rand.NewSource(seed))
+	x := make([]float64, n, n)
+	for i := range x {
+		x[i] = sd * r.NormFloat64()
+	}
+	return x
+}
+
+// PinkNoise returns n samples of pink (1/f) noise with standard deviation sd.
+// Uses Paul Kellet's filter on Gaussian white noise.
+func PinkNoise(n int, sd float64, seed int64) []float64 {
+	r := rand.New(rand.NewSource(seed))
+	x := make([]float64, n, n)
+	var b0, b1, b2, b3, b4, b5, b6 float64
+	for i := range x {
+		w := r.NormFloat64()
+		b0 = 0.99886*b0 + w*0.0555179
+		b1 = 0.99332*b1 + w*0.0750759
+		b2 = 0.96900*b2 + w*0.1538520
+		b3 = 0.86650*b3 + w*0.3104856
+		b4 = 0.55000*b4 + w*0.5329522
+		b5 = -0.7616*b5 - w*0.0168980
+		x[i] = b0 + b1 + b2 + b3 + b4 + b5 + b6 + w*0.5362
+		b6 = w * 0.115926
+	}
+	return normalize(x, sd)
+}
+
+// BrownNoise returns n samples of brown (1/f^2) noise with standard deviation sd.
+// Generated using a leaky integrator on Gaussian white noise.
+func BrownNoise(n int, sd float64, seed int64) []float64 {
+	r := rand.New(rand.NewSource(seed))
+	x := make([]float64, n, n)
+	var y float64
+	for i := range x {
+		y = 0.98*y + r.NormFloat64()
+		x[i] = y
+	}
+	return normalize(x, sd)
+}
+
+// dtmfFreqs maps DTMF digits to the low and high tone frequencies.
+var dtmfFreqs = map[rune][2]float64{
+	'1': {697, 1209}, '2': {697, 1336}, '3': {697, 1477}, 'A': {697, 1633},
+	'4': {770, 1209}, '5': {770, 1336}, '6': {770, 1477}, 'B': {770, 1633},
+	'7': {852, 1209}, '8': {852, 1336}, '9': {852, 1477}, 'C': {852, 1633},
+	'*': {941, 1209}, '0': {941, 1336}, '#': {941, 1477}, 'D': {941, 1633},
+}
+
+// DTMFFreqs returns the low and high frequencies of a DTMF digit.
+func DTMFFreqs(digit rune) (low, high float64, err error) {
+	f, ok := dtmfFreqs[digit]
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid DTMF digit: %q", digit)
+	}
+	return f[0], f[1], nil
+}
+
+// DTMF returns the dual-tone multi-frequency signal for a sequence of digits.
+// Each digit is a tone of toneLen samples followed by gapLen samples of silence.
+// Each of the two sinusoids in a tone has amplitude amp.
+func DTMF(digits string, fs float64, toneLen, gapLen int, amp float64) ([]float64, error) {
+	x := []float64{}
+	for _, d := range digits {
+		low, high, err := DTMFFreqs(d)
+		if err != nil {
+			return nil, err
+		}
+		tone := Add(Sine(toneLen, fs, low, amp, 0), Sine(toneLen, fs, high, amp, 0))
+		x = append(x, tone...)
+		x = append(x, make([]float64, gapLen, gapLen)...)
+	}
+	return x, nil
+}
+
+// Add returns the sample by sample sum of the signals. The length of the
+// result is the length of the longest signal.
+func Add(signals ...[]float64) []float64 {
+	var n int
+	for _, s := range signals {
+		if len(s) > n {
+			n = len(s)
+		}
+	}
+	x := make([]float64, n, n)
+	for _, s := range signals {
+		for i, v := range s {
+			x[i] += v
+		}
+	}
+	return x
+}
+
+// Mix adds noise to signal such that the signal to noise ratio is snr in dB.
+// The noise is scaled, signal is not modified. If the noise is shorter than
+// the signal, it is repeated.
+func Mix(signal, noise []float64, snr float64) []float64 {
+	x := make([]float64, len(signal), len(signal))
+	if len(noise) == 0 {
+		copy(x, signal)
+		return x
+	}
+	var ps, pn float64
+	for i, v := range signal {
+		ps += v * v
+		nv := noise[i%len(noise)]
+		pn += nv * nv
+	}
+	scale := 0.0
+	if pn > 0 {
+		scale = math.Sqrt(ps / (pn * math.Pow(10, snr/10)))
+	}
+	for i, v := range signal {
+		x[i] = v + scale*noise[i%len(noise)]
+	}
+	return x
+}
+
+// SNR returns the signal to noise ratio in dB.
+func SNR(signal, noise []float64) float64 {
+	var ps, pn float64
+	for _, v := range signal {
+		ps += v * v
+	}
+	for _, v := range noise {
+		pn += v * v
+	}
+	return 10 * math.Log10(ps/pn)
+}
+
+// normalize scales x in place so that its standard deviation is sd.
+func normalize(x []float64, sd float64) []float64 {
+	var sumx, sumxsq float64
+	for _, v := range x {
+		sumx += v
+		sumxsq += v * v
+	}
+	n := float64(len(x))
+	mu := sumx / n
+	s := math.Sqrt(sumxsq/n - mu*mu)
+	if s == 0 {
+		return x
+	}
+	for i := range x {
+		x[i] = (x[i] - mu) * sd / s
+	}
+	return x
+}
@@ -0,0 +1,193 @@
+package gen
+
+import (
+	"math"
+	"testing"
+
+	"github.com/akualab/dsp"
+	"github.com/akualab/dsp/proc"
+	narray "github.com/akualab/narray/na64"
+)
+
+// peak returns the index of the max spectral energy bin.
+func peak(t *testing.T, x []float64, logSize int) int {
+	dft := make([]float64, 2<<uint(logSize))
+	copy(dft, x)
+	proc.RealFT(dft, len(dft), true)
+	egy := proc.DFTEnergy(dft)
+	var k int
+	for i, v := range egy {
+		if v > egy[k] {
+			k = i
+		}
+	}
+	return k
+}
+
+func TestSine(t *testing.T) {
+
+	// 1 kHz tone, bin spacing is 8000/512 Hz.
+	x := Sine(512, 8000, 1000, 1, 0)
+	k := peak(t, x, 8)
+	if k != 64 {
+		t.Fatalf("expected peak at bin 64, got %d", k)
+	}
+	var sumsq float64
+	for _, v := range x {
+		sumsq += v * v
+	}
+	rms := math.Sqrt(sumsq / float64(len(x)))
+	if math.Abs(rms-1/math.Sqrt2) > 1e-6 {
+		t.Fatalf("expected rms %f, got %f", 1/math.Sqrt2, rms)
+	}
+}
+
+func TestChirp(t *testing.T) {
+
+	x := Chirp(8000, 8000, 100, 3000, 1)
+	k0 := peak(t, x[:512], 8)
+	k1 := peak(t, x[len(x)-512:], 8)
+	if k0 >= k1 {
+		t.Fatalf("expected increasing frequency, got peaks at %d and %d", k0, k1)
+	}
+}
+
+func TestNoise(t *testing.T) {
+
+	for name, f := range map[string]func(int, float64, int64) []float64{
+		"white": WhiteNoise,
+		"pink":  PinkNoise,
+		"brown": BrownNoise,
+	} {
+		x := f(20000, 0.5, 42)
+		y := f(20000, 0.5, 42)
+		var sumx, sumxsq float64
+		for i, v := range x {
+			if v != y[i] {
+				t.Fatalf("%s noise: same seed must produce the same signal", name)
+			}
+			sumx += v
+			sumxsq += v * v
+		}
+		n := float64(len(x))
+		sd := math.Sqrt(sumxsq/n - (sumx/n)*(sumx/n))
+		if math.Abs(sd-0.5) > 0.02 {
+			t.Fatalf("%s noise: expected sd 0.5, got %f", name, sd)
+		}
+	}
+	x := WhiteNoise(100, 1, 1)
+	y := WhiteNoise(100, 1, 2)
+	if x[0] == y[0] && x[1] == y[1] {
+		t.Fatal("different seeds must produce different signals")
+	}
+}
+
+func TestDTMF(t *testing.T) {
+
+	x, err := DTMF("5#", 8000, 1024, 256, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(x) != 2*(1024+256) {
+		t.Fatalf("expected %d samples, got %d", 2*(1024+256), len(x))
+	}
+	low, high, _ := DTMFFreqs('5')
+	dft := make([]float64, 1024)
+	copy(dft, x[:1024])
+	proc.RealFT(dft, 1024, true)
+	egy := proc.DFTEnergy(dft)
+	kl := int(low*1024/8000 + 0.5)
+	kh := int(high*1024/8000 + 0.5)
+	for k, v := range egy {
+		if k >= kl-1 && k <= kl+1 || k >= kh-1 && k <= kh+1 {
+			continue
+		}
+		if v > egy[kl]/10 || v > egy[kh]/10 {
+			t.Fatalf("unexpected energy at bin %d: %f", k, v)
+		}
+	}
+	if _, err := DTMF("5x", 8000, 100, 10, 1); err == nil {
+		t.Fatal("expected error for invalid digit")
+	}
+}
+
+func TestImpulseTrain(t *testing.T) {
+
+	x := ImpulseTrain(10, 4, 2)
+	for i, v := range x {
+		if (i%4 == 0) != (v == 2) {
+			t.Fatalf("unexpected value %f at %d", v, i)
+		}
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic for zero period")
+		}
+	}()
+	ImpulseTrain(10, 0, 1)
+}
+
+func TestMix(t *testing.T) {
+
+	s := Sine(8000, 8000, 440, 1, 0)
+	n := WhiteNoise(3000, 1, 7)
+	for _, snr := range []float64{-5, 0, 10, 20} {
+		x := Mix(s, n, snr)
+		noise := make([]float64, len(x))
+		for i := range x {
+			noise[i] = x[i] - s[i]
+		}
+		got := SNR(s, noise)
+		if math.Abs(got-snr) > 1e-9 {
+			t.Fatalf("expected snr %f, got %f", snr, got)
+		}
+	}
+}
+
+func TestSource(t *testing.T) {
+
+	x := Impulse(1000, 5, 1)
+	src := NewSource("impulse", x, Fs(8000), FrameSize(100), StepSize(50))
+	if src.NumFrames() != 19 {
+		t.Fatalf("expected 19 frames, got %d", src.NumFrames())
+	}
+	for i := 0; ; i++ {
+		_, err := src.Get(i)
+		if err == dsp.ErrOOB {
+			if i != src.NumFrames() {
+				t.Fatalf("expected %d frames, got %d", src.NumFrames(), i)
+			}
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Step size defaults to the frame size.
+	src = NewSource("impulse", x, FrameSize(256))
+	if src.NumFrames() != 3 {
+		t.Fatalf("expected 3 frames, got %d", src.NumFrames())
+	}
+	if _, err := src.Get(3); err != dsp.ErrOOB {
+		t.Fatalf("expected ErrOOB, got %v", err)
+	}
+
+	// Whole signal with a window processor.
+	app := dsp.NewApp("gen")
+	out := app.Chain(
+		app.Add("spectrum", proc.SpectralEnergy(7)),
+		app.Add("window", proc.NewWindowProc(100, 200, proc.Rectangular, false)),
+		app.Add("source", NewSource("impulse", x)),
+	)
+	v, err := out.Get(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Spectrum of an impulse is flat.
+	for k, e := range v.(*narray.NArray).Data {
+		if math.Abs(e-1) > 1e-9 {
+			t.Fatalf("expected flat spectrum, got %f at bin %d", e, k)
+		}
+	}
+}
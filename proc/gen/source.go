@@ -0,0 +1,90 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gen
+
+import (
+	"github.com/akualab/dsp"
+	narray "github.com/akualab/narray/na64"
+)
+
+const defaultBufSize = 1000
+
+// Source is a source processor that provides access to synthetic signal samples.
+// It has the same frame semantics as wav.SourceProc.
+//
+//go:generate optioner -type Source
+type Source struct {
+	*dsp.Proc `opt:"-"`
+	id        string    `opt:"-"`
+	samples   []float64 `opt:"-"`
+	frameSize int
+	stepSize  int
+	fs        float64
+}
+
+// NewSource creates a source processor for the samples.
+// The samples are partitioned into frames of size frameSize spaced by stepSize samples.
+// To get a single frame with the entire signal use frameSize=0. (This is the
+// input expected by proc.WindowProc.) When stepSize is not set, frames do not overlap.
+func NewSource(id string, samples []float64, options ...optSource) *Source {
+	s := &Source{
+		id:      id,
+		samples: samples,
+	}
+	s.Option(options...)
+	if s.frameSize < 1 {
+		s.frameSize = len(samples)
+		s.stepSize = s.frameSize
+	}
+	if s.stepSize < 1 {
+		s.stepSize = s.frameSize
+	}
+	s.Proc = dsp.NewProc(defaultBufSize, nil)
+	return s
+}
+
+// Get implements the dsp.Processer interface.
+// The value shares storage with the samples; it must be treated as read-only.
+func (src *Source) Get(idx int) (dsp.Value, error) {
+	n := len(src.samples)
+	start := idx * src.stepSize
+	end := start + src.frameSize
+	if start < 0 || start >= n || end > n {
+		return nil, dsp.ErrOOB
+	}
+	return narray.NewArray(src.samples[start:end:end], src.frameSize), nil
+}
+
+// ID returns the id of the signal.
+func (src *Source) ID() string {
+	return src.id
+}
+
+// NumFrames returns the number of frames in the signal.
+func (src *Source) NumFrames() int {
+	if src.stepSize < 1 {
+		return 0
+	}
+	if src.stepSize < src.frameSize {
+		return (len(src.samples) - (src.frameSize - src.stepSize)) / src.stepSize
+	}
+	return len(src.samples) / src.stepSize
+}
+
+// NumSamples returns the number of samples in the signal.
+func (src *Source) NumSamples() int {
+	return len(src.samples)
+}
+
+// Samples returns the signal samples.
+func (src *Source) Samples() []float64 {
+	return src.samples
+}
+
+// FS returns the sampling rate.
+func (src *Source) FS() float64 {
+	return src.fs
+}
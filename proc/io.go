@@ -7,10 +7,13 @@ package proc
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"io"
+	"strconv"
 
 	"github.com/akualab/dsp"
+	narray "github.com/akualab/narray/na64"
 )
 
 type ValueType int
@@ -47,3 +50,85 @@ func WriteValues(writer io.Writer, on bool) dsp.Processer {
 		return v, nil
 	})
 }
+
+/*
+FeatureWriter is a sink that writes each input vector to a writer as a line of space separated
+values and emits the vector. The first time frame zero is processed after a reset, a header
+with the stream ID (when a context is set) and the layout in JSON format is written:
+
+	# id utt1
+	# layout [{"name":"log energy","start":0,"end":1},...]
+
+The layout is typically obtained using dsp.App.Layout so downstream tools can select dimensions by name.
+Frames must be requested in order.
+*/
+type FeatureWriter struct {
+	writer  io.Writer
+	layout  dsp.Layout
+	started bool
+	*dsp.Proc
+}
+
+// NewFeatureWriter returns a new feature writer. The layout may be nil.
+func NewFeatureWriter(writer io.Writer, layout dsp.Layout) *FeatureWriter {
+	return &FeatureWriter{
+		writer: writer,
+		layout: layout,
+		Proc:   dsp.NewProc(defaultBufSize, nil),
+	}
+}
+
+// Get implements the dsp.Processer interface.
+func (fw *FeatureWriter) Get(idx int) (dsp.Value, error) {
+	if idx < 0 {
+		return nil, dsp.ErrOOB
+	}
+	val, ok := fw.GetCache(idx)
+	if ok {
+		return val, nil
+	}
+	v, err := dsp.Processers(fw.Inputs()).Get(idx)
+	if err != nil {
+		return nil, err
+	}
+	b := bufio.NewWriter(fw.writer)
+	if idx == 0 && !fw.started {
+		if ctx := fw.Context(); ctx != nil {
+			fmt.Fprintf(b, "# id %s\n", ctx.ID)
+		}
+		if fw.layout != nil {
+			js, err := json.Marshal(fw.layout)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Fprintf(b, "# layout %s\n", js)
+		}
+		fw.started = true
+	}
+	for i, x := range v.(*narray.NArray).Data {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(strconv.FormatFloat(x, 'g', -1, 64))
+	}
+	b.WriteByte('\n')
+	if err := b.Flush(); err != nil {
+		return nil, err
+	}
+	fw.SetCache(idx, v)
+	return v, nil
+}
+
+// Reset implements the dsp.Resetter interface. The header is written again for the next stream.
+func (fw *FeatureWriter) Reset() {
+	fw.Proc.Reset()
+	fw.started = false
+}
+
+// Layout implements the dsp.Layouter interface.
+func (fw *FeatureWriter) Layout(inputs []string, layouts []dsp.Layout) (dsp.Layout, error) {
+	if fw.layout == nil {
+		return nil, fmt.Errorf("feature writer has no layout")
+	}
+	return fw.layout, nil
+}
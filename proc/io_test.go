@@ -0,0 +1,53 @@
+package proc
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/akualab/dsp"
+)
+
+func TestFeatureWriter(t *testing.T) {
+
+	var buf bytes.Buffer
+	layout := dsp.Layout{{Name: "a", Start: 0, End: 1}, {Name: "b", Start: 1, End: 2}}
+	app := dsp.NewApp("writer")
+	out := app.Chain(
+		app.Add("writer", NewFeatureWriter(&buf, layout)),
+		app.Add("frames", frames([][]float64{{1, 2}, {3, 4.5}})),
+	)
+	app.SetContext(&dsp.Context{ID: "utt1"})
+	for i := 0; i < 2; i++ {
+		if _, err := out.Get(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Cached values are not written again.
+	if _, err := out.Get(1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := out.Get(2); err != dsp.ErrOOB {
+		t.Fatalf("expected ErrOOB, got %v", err)
+	}
+	expected := "# id utt1\n" +
+		`# layout [{"name":"a","start":0,"end":1},{"name":"b","start":1,"end":2}]` + "\n" +
+		"1 2\n3 4.5\n"
+	if buf.String() != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+	l, err := app.Layout("writer")
+	if err != nil || l.Dim() != 2 {
+		t.Fatalf("unexpected layout %v, error: %v", l, err)
+	}
+
+	// The header is written for each stream.
+	buf.Reset()
+	app.Reset()
+	app.SetContext(&dsp.Context{ID: "utt2"})
+	if _, err := out.Get(0); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("# id utt2\n# layout")) {
+		t.Fatalf("unexpected output: %s", buf.String())
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package proc
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/akualab/dsp"
+	narray "github.com/akualab/narray/na64"
+)
+
+/*
+CheckCOLA checks the constant overlap-add condition for window w and hop size step.
+The condition holds when the shifted windows add up to a constant:
+
+	sum w[n - m*step] = C  for all n
+	 m
+
+Returns the value of C and an error if the maximum deviation from the mean value is larger than tol.
+*/
+func CheckCOLA(w []float64, step int, tol float64) (float64, error) {
+	if step < 1 || step > len(w) {
+		return 0, fmt.Errorf("step size must be between 1 and the window size [%d], got %d", len(w), step)
+	}
+	sum := make([]float64, step, step)
+	for i, v := range w {
+		sum[i%step] += v
+	}
+	var mean float64
+	for _, v := range sum {
+		mean += v
+	}
+	mean /= float64(step)
+	for n, v := range sum {
+		if math.Abs(v-mean) > tol {
+			return mean, fmt.Errorf("COLA condition does not hold for step size %d, sum at %d is %f, mean is %f", step, n, v, mean)
+		}
+	}
+	return mean, nil
+}
+
+/*
+ISTFTProc computes the inverse short-time Fourier transform using weighted overlap-add.
+The input values are complex frames of type []complex128 as returned by STFT. The frames must
+have been produced using the WindowProc passed to NewISTFTProc. The output is the reconstructed
+waveform which is returned for index zero, ErrOOB is returned for other indices. (This is the
+same convention used by sources that provide the entire waveform.)
+
+The frames are windowed again using the analysis window and the waveform is reconstructed as follows:
+
+	       sum w[n - p_m] y_m[n - p_m]
+	        m
+	x[n] = ---------------------------
+	          sum w^2[n - p_m]
+	           m
+
+where y_m is the inverse DFT of frame m and p_m is the position of the frame in the waveform.
+Samples that are not covered by any frame are set to zero.
+*/
+type ISTFTProc struct {
+	fftSize  int
+	stepSize int
+	winSize  int
+	centered bool
+	win      []float64
+	cola     bool
+	plan     *FFT
+	*dsp.Proc
+}
+
+// NewISTFTProc returns an inverse STFT processor for frames produced using win and STFT(fftSize).
+// Returns an error if the frames do not overlap in a way that allows reconstruction.
+func NewISTFTProc(win *WindowProc, fftSize int) (*ISTFTProc, error) {
+	if win.err != nil {
+		return nil, win.err
+	}
+	if win.WinSize > fftSize {
+		return nil, fmt.Errorf("window size [%d] is larger than FFT size [%d]", win.WinSize, fftSize)
+	}
+	if win.StepSize < 1 {
+		return nil, fmt.Errorf("step size must be positive, got %d", win.StepSize)
+	}
+	if win.StepSize > win.WinSize {
+		return nil, fmt.Errorf("step size [%d] is larger than window size [%d], frames do not overlap", win.StepSize, win.WinSize)
+	}
+	sq := make([]float64, len(win.data), len(win.data))
+	var total float64
+	for i, v := range win.data {
+		sq[i] = v * v
+		total += sq[i]
+	}
+	// The reconstruction requires that every sample is covered by a non-zero window value.
+	for n := 0; n < win.StepSize; n++ {
+		var s float64
+		for i := n; i < len(sq); i += win.StepSize {
+			s += sq[i]
+		}
+		if s < 1e-10 {
+			return nil, fmt.Errorf("window and step size combination cannot be inverted, zero sum of squared windows at offset %d", n)
+		}
+	}
+	_, colaErr := CheckCOLA(sq, win.StepSize, 1e-9*total/float64(win.StepSize))
+	plan, err := NewFFT(fftSize, NormBackward)
+	if err != nil {
+		return nil, err
+	}
+	return &ISTFTProc{
+		fftSize:  fftSize,
+		stepSize: win.StepSize,
+		winSize:  win.WinSize,
+		centered: win.Centered,
+		win:      win.data,
+		cola:     colaErr == nil,
+		plan:     plan,
+		Proc:     dsp.NewProc(1, nil),
+	}, nil
+}
+
+// COLA returns true if the squared window satisfies the constant overlap-add condition.
+// In this case, the normalization in the reconstruction formula is constant except near
+// the edges of the waveform.
+func (p *ISTFTProc) COLA() bool {
+	return p.cola
+}
+
+// Get implements the dsp.Framer interface.
+func (p *ISTFTProc) Get(idx int) (dsp.Value, error) {
+	if idx != 0 {
+		return nil, dsp.ErrOOB
+	}
+	val, ok := p.GetCache(idx)
+	if ok {
+		return val, nil
+	}
+	num := []float64{}
+	den := []float64{}
+	y := make([]float64, p.fftSize, p.fftSize)
+	for m := 0; ; m++ {
+		spec, err := complexFrame(m, p.Inputs())
+		if err == dsp.ErrOOB {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(spec) != p.fftSize/2+1 {
+			return nil, fmt.Errorf("expected frame with %d values, got %d", p.fftSize/2+1, len(spec))
+		}
+		p.plan.InverseReal(y, spec)
+
+		// Same frame position as WindowProc.
+		pos := m * p.stepSize
+		if p.centered {
+			pos += p.stepSize/2 - p.winSize/2
+		}
+		end := pos + p.winSize
+		for len(num) < end {
+			num = append(num, 0)
+			den = append(den, 0)
+		}
+		for i := 0; i < p.winSize; i++ {
+			n := pos + i
+			if n < 0 {
+				// The window processor reflects the waveform at the start.
+				continue
+			}
+			num[n] += p.win[i] * y[i]
+			den[n] += p.win[i] * p.win[i]
+		}
+	}
+	for n := range num {
+		if den[n] > 1e-10 {
+			num[n] /= den[n]
+		} else {
+			num[n] = 0
+		}
+	}
+	v := narray.NewArray(num, len(num))
+	p.SetCache(idx, v)
+	return v, nil
+}
@@ -0,0 +1,94 @@
+package proc
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/akualab/dsp"
+	narray "github.com/akualab/narray/na64"
+)
+
+func TestCheckCOLA(t *testing.T) {
+
+	for _, c := range []struct {
+		w    []float64
+		step int
+		sum  float64
+		ok   bool
+	}{
+		{HanningWindow(256), 128, 1, true},
+		{HanningWindow(256), 64, 2, true},
+		{HammingWindow(256), 128, 1.08, true},
+		{RectangularWindow(100), 100, 1, true},
+		{RectangularWindow(100), 30, 0, false},
+		{BlackmanWindow(256), 128, 0, false},
+		{BlackmanWindow(256), 64, 1.68, true},
+	} {
+		sum, err := CheckCOLA(c.w, c.step, 1e-9)
+		if c.ok != (err == nil) {
+			t.Fatalf("win size %d, step %d: expected ok=%t, got error %v", len(c.w), c.step, c.ok, err)
+		}
+		if c.ok {
+			compareFloats(t, c.sum, sum, "cola sum", 1e-9)
+		}
+	}
+}
+
+func TestISTFT(t *testing.T) {
+
+	r := rand.New(rand.NewSource(7))
+	x := make([]float64, 4000)
+	for i := range x {
+		x[i] = r.NormFloat64()
+	}
+	for _, c := range []struct {
+		step, size, typ int
+		centered, cola  bool
+	}{
+		{128, 256, Hanning, false, false},
+		{64, 256, Hanning, true, true},
+		{80, 205, Hamming, true, false},
+		{100, 200, Rectangular, false, true},
+	} {
+		app := dsp.NewApp("istft")
+		win := NewWindowProc(c.step, c.size, c.typ, c.centered)
+		istft, err := NewISTFTProc(win, 256)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if istft.COLA() != c.cola {
+			t.Fatalf("step %d, size %d: expected COLA %t", c.step, c.size, c.cola)
+		}
+		out := app.Chain(
+			app.Add("istft", istft),
+			app.Add("stft", STFT(256)),
+			app.Add("window", win),
+			app.Add("wav", wavSP(x)),
+		)
+		v, err := out.Get(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		y := v.(*narray.NArray).Data
+		if len(y) > len(x) || len(y) < len(x)-c.size {
+			t.Fatalf("unexpected output length %d", len(y))
+		}
+		// Compare away from the edges.
+		for n := c.size; n < len(y)-c.size; n++ {
+			if math.Abs(x[n]-y[n]) > 1e-9 {
+				t.Fatalf("step %d, size %d: sample %d - expected %f, got %f", c.step, c.size, n, x[n], y[n])
+			}
+		}
+		if _, err := out.Get(1); err != dsp.ErrOOB {
+			t.Fatalf("expected ErrOOB, got %v", err)
+		}
+	}
+
+	if _, err := NewISTFTProc(NewWindowProc(300, 256, Hanning, false), 256); err == nil {
+		t.Fatal("expected error for step size larger than window")
+	}
+	if _, err := NewISTFTProc(NewWindowProc(128, 512, Hanning, false), 256); err == nil {
+		t.Fatal("expected error for window larger than FFT")
+	}
+}
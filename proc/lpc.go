@@ -0,0 +1,297 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package proc
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"sort"
+
+	"github.com/akualab/dsp"
+	narray "github.com/akualab/narray/na64"
+)
+
+/*
+Levinson solves the normal equations of linear prediction of order p using the Levinson-Durbin
+recursion. Param r has the autocorrelation values r[0],...,r[p]. The prediction error filter is:
+
+	A(z) = 1 + a[1] z^-1 + ... + a[p] z^-p
+
+Returns a[1],...,a[p], the reflection coefficients k[1],...,k[p] and the prediction error.
+If r[0] is not positive, the coefficients are zero and the error is zero. If the recursion becomes
+unstable due to numerical errors, the remaining coefficients are zero.
+*/
+func Levinson(r []float64, p int) (a, k []float64, e float64) {
+	a = make([]float64, p, p)
+	k = make([]float64, p, p)
+	if len(r) <= p {
+		panic(fmt.Sprintf("need %d autocorrelation values for order %d, got %d", p+1, p, len(r)))
+	}
+	if r[0] <= 0 {
+		return a, k, 0
+	}
+	e = r[0]
+	tmp := make([]float64, p, p)
+	for i := 0; i < p; i++ {
+		acc := r[i+1]
+		for j := 0; j < i; j++ {
+			acc += a[j] * r[i-j]
+		}
+		ki := -acc / e
+		if math.Abs(ki) >= 1 {
+			break
+		}
+		k[i] = ki
+		copy(tmp, a[:i])
+		for j := 0; j < i; j++ {
+			a[j] = tmp[j] + ki*tmp[i-1-j]
+		}
+		a[i] = ki
+		e *= 1 - ki*ki
+	}
+	return a, k, e
+}
+
+// Autocorrelation returns the autocorrelation values r[0],...,r[order] of the input frame.
+//
+//	r[i] = sum_n x[n] x[n+i]
+func Autocorrelation(order int) dsp.Processer {
+	return dsp.NewProc(defaultBufSize, func(idx int, in ...dsp.Processer) (dsp.Value, error) {
+		vec, err := dsp.Processers(in).Get(idx)
+		if err != nil {
+			return nil, err
+		}
+		x := vec.(*narray.NArray).Data
+		if len(x) <= order {
+			return nil, fmt.Errorf("frame size [%d] must be larger than the order [%d]", len(x), order)
+		}
+		r := narray.New(order + 1)
+		for i := range r.Data {
+			for n := 0; n+i < len(x); n++ {
+				r.Data[i] += x[n] * x[n+i]
+			}
+		}
+		return r, nil
+	})
+}
+
+// lpcProc returns a processor that applies f to the LPC analysis of the autocorrelation values
+// in the input vectors.
+func lpcProc(order int, f func(a, k []float64, e float64) []float64) dsp.Processer {
+	return dsp.NewProc(defaultBufSize, func(idx int, in ...dsp.Processer) (dsp.Value, error) {
+		vec, err := dsp.Processers(in).Get(idx)
+		if err != nil {
+			return nil, err
+		}
+		r := vec.(*narray.NArray).Data
+		if len(r) <= order {
+			return nil, fmt.Errorf("need %d autocorrelation values for order %d, got %d", order+1, order, len(r))
+		}
+		v := f(Levinson(r, order))
+		return narray.NewArray(v, len(v)), nil
+	})
+}
+
+// LPC returns the linear prediction coefficients a[1],...,a[order]. The input is the output of
+// Autocorrelation. See Levinson for details.
+func LPC(order int) dsp.Processer {
+	return lpcProc(order, func(a, k []float64, e float64) []float64 { return a })
+}
+
+// Reflection returns the reflection (PARCOR) coefficients k[1],...,k[order]. The input is the output of
+// Autocorrelation. See Levinson for details.
+func Reflection(order int) dsp.Processer {
+	return lpcProc(order, func(a, k []float64, e float64) []float64 { return k })
+}
+
+/*
+LPCToCepstrum returns n cepstral coefficients c[1],...,c[n] of the all-pole model 1/A(z) where
+a has the linear prediction coefficients a[1],...,a[p]. The recursion is:
+
+	c[m] = -a[m] - sum_{k=1}^{m-1} (k/m) c[k] a[m-k]
+
+where a[m] = 0 for m > p. The gain term c[0] is not included.
+*/
+func LPCToCepstrum(a []float64, n int) []float64 {
+	p := len(a)
+	c := make([]float64, n+1, n+1)
+	for m := 1; m <= n; m++ {
+		if m <= p {
+			c[m] = -a[m-1]
+		}
+		for k := 1; k < m; k++ {
+			if m-k <= p {
+				c[m] -= float64(k) / float64(m) * c[k] * a[m-k-1]
+			}
+		}
+	}
+	return c[1:]
+}
+
+// LPCC returns numCep LPC-cepstrum coefficients. The input is the output of LPC. See LPCToCepstrum.
+func LPCC(numCep int) dsp.Processer {
+	return dsp.NewProc(defaultBufSize, func(idx int, in ...dsp.Processer) (dsp.Value, error) {
+		vec, err := dsp.Processers(in).Get(idx)
+		if err != nil {
+			return nil, err
+		}
+		c := LPCToCepstrum(vec.(*narray.NArray).Data, numCep)
+		return narray.NewArray(c, len(c)), nil
+	})
+}
+
+// lsfGrid is the number of points used to search for the roots of the LSF polynomials.
+const lsfGrid = 1024
+
+/*
+LPCToLSF converts the linear prediction coefficients a[1],...,a[p] to line spectral frequencies.
+The LSFs are the angles in radians of the roots of
+
+	P(z) = A(z) + z^-(p+1) A(1/z)
+	Q(z) = A(z) - z^-(p+1) A(1/z)
+
+on the unit circle, excluding z=1 and z=-1. The p values are sorted in increasing order in (0, Pi).
+Returns an error if the roots are not found, which may happen when A(z) is not minimum phase.
+*/
+func LPCToLSF(a []float64) ([]float64, error) {
+	p := len(a)
+	coef := make([]float64, p+2, p+2)
+	coef[0] = 1
+	copy(coef[1:], a)
+	pc := make([]float64, p+2, p+2)
+	qc := make([]float64, p+2, p+2)
+	for i := range pc {
+		pc[i] = coef[i] + coef[p+1-i]
+		qc[i] = coef[i] - coef[p+1-i]
+	}
+	// The polynomials are symmetric and antisymmetric so the following functions are real.
+	eval := func(c []float64, w float64, sym bool) float64 {
+		var sum complex128
+		for i, v := range c {
+			sum += complex(v, 0) * cmplx.Rect(1, -w*float64(i))
+		}
+		sum *= cmplx.Rect(1, w*float64(p+1)/2)
+		if sym {
+			return real(sum)
+		}
+		return imag(sum)
+	}
+	lsf := []float64{}
+	for _, poly := range []struct {
+		c   []float64
+		sym bool
+	}{{pc, true}, {qc, false}} {
+		f := func(w float64) float64 { return eval(poly.c, w, poly.sym) }
+		step := math.Pi / lsfGrid
+		w0 := step / 2
+		f0 := f(w0)
+		for i := 1; i < lsfGrid; i++ {
+			w1 := float64(i)*step + step/2
+			f1 := f(w1)
+			if f0*f1 < 0 || f1 == 0 {
+				lo, hi, flo := w0, w1, f0
+				for j := 0; j < 50; j++ {
+					mid := (lo + hi) / 2
+					fm := f(mid)
+					if fm*flo <= 0 {
+						hi = mid
+					} else {
+						lo, flo = mid, fm
+					}
+				}
+				lsf = append(lsf, (lo+hi)/2)
+			}
+			w0, f0 = w1, f1
+		}
+	}
+	if len(lsf) != p {
+		return nil, fmt.Errorf("found %d line spectral frequencies, expected %d", len(lsf), p)
+	}
+	sort.Float64s(lsf)
+	return lsf, nil
+}
+
+// LSF returns the line spectral frequencies in radians. The input is the output of LPC. See LPCToLSF.
+func LSF() dsp.Processer {
+	return dsp.NewProc(defaultBufSize, func(idx int, in ...dsp.Processer) (dsp.Value, error) {
+		vec, err := dsp.Processers(in).Get(idx)
+		if err != nil {
+			return nil, err
+		}
+		lsf, err := LPCToLSF(vec.(*narray.NArray).Data)
+		if err != nil {
+			return nil, err
+		}
+		return narray.NewArray(lsf, len(lsf)), nil
+	})
+}
+
+// EqualLoudness returns the equal-loudness weight used in PLP for frequency f in Hertz.
+// (An approximation to the sensitivity of human hearing at 40 dB.)
+//
+//	E(w) = (w^2 + 56.8e6) w^4 / ((w^2 + 6.3e6)^2 (w^2 + 0.38e9)),  w = 2 Pi f
+func EqualLoudness(f float64) float64 {
+	w2 := (2 * math.Pi * f) * (2 * math.Pi * f)
+	return (w2 + 56.8e6) * w2 * w2 / ((w2 + 6.3e6) * (w2 + 6.3e6) * (w2 + 0.38e9))
+}
+
+/*
+PLP computes perceptual linear prediction cepstral coefficients. (H. Hermansky, "Perceptual linear
+predictive (PLP) analysis of speech", JASA 1990.) The input is a power spectrum with n values where
+value k corresponds to frequency k*fs/(2n), for example, the output of SpectralEnergy. An input with
+n+1 values is also accepted, the value at fs/2 is ignored. (For example, the output of Power with fftSize=2n.)
+
+The power spectrum is integrated using numBands triangular filters on the Bark scale between 0 and fs/2,
+weighted using the equal-loudness curve and compressed using the cube root. The all-pole model of
+the given order is estimated from the autocorrelation values obtained from the inverse DFT of the
+auditory spectrum. The output has numCep cepstral coefficients c[1],...,c[numCep] of the all-pole model.
+*/
+func PLP(fs float64, n, numBands, order, numCep int) dsp.Processer {
+	indices, coeff, err := GenerateScaleFilterbank(n, numBands, fs, 0, fs/2, Bark, 0)
+	weights := FilterCenters(numBands, 0, fs/2, Bark)
+	for i, f := range weights {
+		weights[i] = EqualLoudness(f)
+	}
+	// Auditory spectrum with the first and last bands repeated at 0 and fs/2.
+	m := numBands + 2
+	s := make([]float64, m, m)
+	r := make([]float64, order+1, order+1)
+	return dsp.NewProc(defaultBufSize, func(idx int, in ...dsp.Processer) (dsp.Value, error) {
+		if err != nil {
+			return nil, err
+		}
+		vec, e := dsp.Processers(in).Get(idx)
+		if e != nil {
+			return nil, e
+		}
+		data := vec.(*narray.NArray).Data
+		if len(data) != n && len(data) != n+1 {
+			return nil, fmt.Errorf("mismatch in size [%d] and input frame size [%d]", n, len(data))
+		}
+		for i := range indices {
+			var egy float64
+			for k, w := range coeff[i] {
+				egy += data[indices[i]+k] * w
+			}
+			s[i+1] = math.Cbrt(egy * weights[i])
+		}
+		s[0] = s[1]
+		s[m-1] = s[m-2]
+
+		// Inverse DFT of the real and even spectrum.
+		for i := range r {
+			r[i] = s[0] + math.Pow(-1, float64(i))*s[m-1]
+			for j := 1; j < m-1; j++ {
+				r[i] += 2 * s[j] * math.Cos(math.Pi*float64(i*j)/float64(m-1))
+			}
+			r[i] /= float64(2 * (m - 1))
+		}
+		a, _, _ := Levinson(r, order)
+		c := LPCToCepstrum(a, numCep)
+		return narray.NewArray(c, len(c)), nil
+	})
+}
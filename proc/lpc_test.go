@@ -0,0 +1,173 @@
+package proc
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/akualab/dsp"
+	narray "github.com/akualab/narray/na64"
+)
+
+func TestLevinson(t *testing.T) {
+
+	// AR(1) process with coefficient 0.9.
+	r := []float64{1, 0.9, 0.81, 0.729}
+	a, k, e := Levinson(r, 2)
+	compareSliceFloat(t, []float64{-0.9, 0}, a, "lpc", 1e-12)
+	compareSliceFloat(t, []float64{-0.9, 0}, k, "reflection", 1e-12)
+	compareFloats(t, 0.19, e, "error", 1e-12)
+
+	a, _, e = Levinson([]float64{0, 0, 0}, 2)
+	compareSliceFloat(t, []float64{0, 0}, a, "zero lpc", 1e-15)
+	compareFloats(t, 0, e, "zero error", 1e-15)
+}
+
+func TestLPC(t *testing.T) {
+
+	// AR(2) signal.
+	rnd := rand.New(rand.NewSource(5))
+	x := make([]float64, 400)
+	for n := range x {
+		x[n] = rnd.NormFloat64()
+		if n > 1 {
+			x[n] += 1.3*x[n-1] - 0.6*x[n-2]
+		}
+	}
+	order := 6
+	app := dsp.NewApp("lpc")
+	acNode := app.Chain(
+		app.Add("autocorrelation", Autocorrelation(order)),
+		app.Add("frame", wavSP(x)),
+	)
+	lpc := app.Connect(app.Add("lpc", LPC(order)), acNode)
+	refl := app.Connect(app.Add("reflection", Reflection(order)), acNode)
+	lsf := app.Connect(app.Add("lsf", LSF()), lpc)
+	lpcc := app.Connect(app.Add("lpcc", LPCC(12)), lpc)
+
+	v, err := acNode.Get(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := v.(*narray.NArray).Data
+	v, err = lpc.Get(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := v.(*narray.NArray).Data
+	if len(a) != order {
+		t.Fatalf("expected %d coefficients, got %d", order, len(a))
+	}
+	// Normal equations.
+	for i := 1; i <= order; i++ {
+		sum := r[i]
+		for j := 1; j <= order; j++ {
+			d := i - j
+			if d < 0 {
+				d = -d
+			}
+			sum += a[j-1] * r[d]
+		}
+		compareFloats(t, 0, sum/r[0], "normal equations", 1e-9)
+	}
+	a2, _, _ := Levinson(r, 2)
+	compareFloats(t, -1.3, a2[0], "a1", 0.1)
+	compareFloats(t, 0.6, a2[1], "a2", 0.1)
+
+	v, err = refl.Get(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range v.(*narray.NArray).Data {
+		if math.Abs(k) >= 1 {
+			t.Fatalf("reflection coefficient out of range: %f", k)
+		}
+	}
+
+	v, err = lsf.Get(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := v.(*narray.NArray).Data
+	if len(w) != order {
+		t.Fatalf("expected %d LSFs, got %d", order, len(w))
+	}
+	for i := range w {
+		if w[i] <= 0 || w[i] >= math.Pi || (i > 0 && w[i] <= w[i-1]) {
+			t.Fatalf("LSFs must be increasing in (0, Pi), got %v", w)
+		}
+	}
+
+	v, err = lpcc.Get(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(v.(*narray.NArray).Data) != 12 {
+		t.Fatalf("expected 12 cepstral coefficients, got %d", len(v.(*narray.NArray).Data))
+	}
+}
+
+func TestLPCConversions(t *testing.T) {
+
+	// log(1/(1 - 0.9 z^-1)) = sum 0.9^m/m z^-m
+	c := LPCToCepstrum([]float64{-0.9}, 5)
+	for m := 1; m <= 5; m++ {
+		compareFloats(t, math.Pow(0.9, float64(m))/float64(m), c[m-1], "cepstrum", 1e-12)
+	}
+
+	lsf, err := LPCToLSF([]float64{-0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareSliceFloat(t, []float64{math.Pi / 3}, lsf, "lsf", 1e-9)
+}
+
+func TestPLP(t *testing.T) {
+
+	compareFloats(t, 0.1706, EqualLoudness(1000), "equal loudness", 1e-3)
+
+	rnd := rand.New(rand.NewSource(9))
+	spec := make([]float64, 256)
+	for k := range spec {
+		spec[k] = rnd.ExpFloat64() * math.Exp(-float64(k)/50)
+	}
+	app := dsp.NewApp("plp")
+	out := app.Chain(
+		app.Add("plp", PLP(16000, 256, 21, 12, 13)),
+		app.Add("spectrum", wavSP(spec)),
+	)
+	v, err := out.Get(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := v.(*narray.NArray).Data
+	if len(c) != 13 {
+		t.Fatalf("expected 13 coefficients, got %d", len(c))
+	}
+	for i, x := range c {
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			t.Fatalf("bad coefficient %d: %f", i, x)
+		}
+	}
+	// The cepstrum does not depend on the gain.
+	scaled := make([]float64, len(spec))
+	for k := range spec {
+		scaled[k] = 10 * spec[k]
+	}
+	out2 := app.Chain(
+		app.Add("plp scaled", PLP(16000, 256, 21, 12, 13)),
+		app.Add("scaled spectrum", wavSP(scaled)),
+	)
+	v, err = out2.Get(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareSliceFloat(t, c, v.(*narray.NArray).Data, "plp gain", 1e-9)
+	bad := app.Chain(
+		app.Add("bad plp", PLP(16000, 128, 21, 12, 13)),
+		app.NodeByName("spectrum"),
+	)
+	if _, err := bad.Get(0); err == nil {
+		t.Fatal("expected error for wrong spectrum size")
+	}
+}
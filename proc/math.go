@@ -304,3 +304,44 @@ func fbCoeff(mid int) []float64 {
 	}
 	return coeff
 }
+
+/*
+Convolve returns the linear convolution of x and h computed using RealFT.
+The length of the output is len(x)+len(h)-1.
+
+           M-1
+    y[n] = sum h[k] * x[n-k]
+           k=0
+*/
+func Convolve(x, h []float64) []float64 {
+	if len(x) == 0 || len(h) == 0 {
+		return []float64{}
+	}
+	ny := len(x) + len(h) - 1
+	n := 2
+	for n < ny {
+		n <<= 1
+	}
+	a := make([]float64, n, n)
+	b := make([]float64, n, n)
+	copy(a, x)
+	copy(b, h)
+	RealFT(a, n, true)
+	RealFT(b, n, true)
+
+	// The first two values are real (DC and Nyquist), the rest are {Re, Im} pairs.
+	a[0] *= b[0]
+	a[1] *= b[1]
+	for i := 2; i < n; i += 2 {
+		re := a[i]*b[i] - a[i+1]*b[i+1]
+		im := a[i]*b[i+1] + a[i+1]*b[i]
+		a[i], a[i+1] = re, im
+	}
+	RealFT(a, n, false)
+	scale := 2.0 / float64(n)
+	y := make([]float64, ny, ny)
+	for i := range y {
+		y[i] = a[i] * scale
+	}
+	return y
+}
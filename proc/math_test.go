@@ -1,6 +1,9 @@
 package proc
 
-import "testing"
+import (
+	"math"
+	"testing"
+)
 
 /*
    Real Input sequence N=16:
@@ -75,3 +78,17 @@ func TestGenerateFilterbank(t *testing.T) {
 	t.Log(coeff)
 
 }
+
+func TestConvolve(t *testing.T) {
+
+	y := Convolve([]float64{1, 2, 3}, []float64{0, 1, 0.5})
+	exp := []float64{0, 1, 2.5, 4, 1.5}
+	if len(y) != len(exp) {
+		t.Fatalf("expected %d samples, got %d", len(exp), len(y))
+	}
+	for i := range exp {
+		if math.Abs(exp[i]-y[i]) > 1e-9 {
+			t.Fatalf("sample %d - expected %f, got %f", i, exp[i], y[i])
+		}
+	}
+}
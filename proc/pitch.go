@@ -0,0 +1,266 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package proc
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/akualab/dsp"
+	narray "github.com/akualab/narray/na64"
+)
+
+// Default pitch tracker parameters.
+const (
+	DefaultMinF0          = 60.0
+	DefaultMaxF0          = 400.0
+	DefaultPitchThreshold = 0.2
+)
+
+/*
+PitchProc estimates the fundamental frequency (F0) of the input waveform. The input is the entire
+waveform which is read from index zero of input 0. (As returned by sources with frame size zero.)
+The output for frame idx is a vector with two values: the F0 in Hertz (zero for unvoiced frames)
+and the voicing probability. The frames are aligned with the frames of a WindowProc created with
+the same step size, window size and centered parameters, and the number of frames is the same.
+
+F0 candidates are estimated for every frame using the YIN algorithm. (A. de Cheveigne and H. Kawahara,
+"YIN, a fundamental frequency estimator for speech and music", JASA 2002.) For each frame, the
+cumulative mean normalized difference function is computed:
+
+	d(tau) = sum_j (x[j] - x[j+tau])^2
+
+	d'(tau) = d(tau) / ((1/tau) sum_{k=1}^{tau} d(k))
+
+The local minima of d' between fs/MaxF0 and fs/MinF0 are the voiced candidates with cost d'(tau). The
+unvoiced candidate has cost Threshold. The F0 track is the sequence of candidates with lowest total cost
+computed using the Viterbi algorithm. The transition cost between voiced frames is OctaveCost times the
+distance in octaves, the cost of switching between voiced and unvoiced frames is VoicingCost.
+The voicing probability is 1 - d'(tau) for the best candidate of the frame, clipped to [0,1].
+
+The track is computed for the entire waveform the first time a frame is requested.
+*/
+type PitchProc struct {
+	// Threshold is the cost of the unvoiced candidate.
+	Threshold float64
+	// OctaveCost is the transition cost per octave between voiced frames.
+	OctaveCost float64
+	// VoicingCost is the cost of switching between voiced and unvoiced frames.
+	VoicingCost float64
+	// NumCandidates is the maximum number of voiced candidates per frame.
+	NumCandidates int
+
+	fs       float64
+	minLag   int
+	maxLag   int
+	stepSize int
+	winSize  int
+	centered bool
+	frames   []*narray.NArray
+	err      error
+	*dsp.Proc
+}
+
+// NewPitchProc returns a pitch tracker for F0 values between minF0 and maxF0 Hertz.
+// See NewWindowProc for the frame parameters.
+func NewPitchProc(fs, minF0, maxF0 float64, stepSize, winSize int, centered bool) *PitchProc {
+	pp := &PitchProc{
+		Threshold:     DefaultPitchThreshold,
+		OctaveCost:    0.5,
+		VoicingCost:   0.2,
+		NumCandidates: 5,
+		fs:            fs,
+		stepSize:      stepSize,
+		winSize:       winSize,
+		centered:      centered,
+		err:           checkGrid(fs, winSize, stepSize),
+		Proc:          dsp.NewProc(defaultBufSize, nil),
+	}
+	if minF0 <= 0 || maxF0 <= minF0 || maxF0 >= fs/2 {
+		pp.err = fmt.Errorf("bad F0 range, got min:%f, max:%f, fs:%f", minF0, maxF0, fs)
+		return pp
+	}
+	pp.minLag = int(math.Floor(fs / maxF0))
+	pp.maxLag = int(math.Ceil(fs / minF0))
+	if pp.minLag < 2 {
+		pp.minLag = 2
+	}
+	return pp
+}
+
+// Get implements the dsp.Framer interface.
+func (pp *PitchProc) Get(idx int) (dsp.Value, error) {
+	if pp.err != nil {
+		return nil, pp.err
+	}
+	if idx < 0 {
+		return nil, dsp.ErrOOB
+	}
+	if pp.frames == nil {
+		if err := pp.track(); err != nil {
+			return nil, err
+		}
+	}
+	if idx >= len(pp.frames) {
+		return nil, dsp.ErrOOB
+	}
+	return pp.frames[idx], nil
+}
+
+// Reset implements the dsp.Resetter interface.
+func (pp *PitchProc) Reset() {
+	pp.frames = nil
+	pp.Proc.Reset()
+}
+
+type pitchCandidate struct {
+	lag  float64 // zero for unvoiced
+	cost float64
+}
+
+// track computes the F0 track for the entire waveform.
+func (pp *PitchProc) track() error {
+	vec, err := dsp.Processers(pp.Inputs()).Get(0)
+	if err != nil {
+		return err
+	}
+	x := vec.(*narray.NArray).Data
+
+	// Same frame positions as WindowProc.
+	var cands [][]pitchCandidate
+	var probs []float64
+	d := make([]float64, pp.maxLag+2, pp.maxLag+2)
+	for idx := 0; ; idx++ {
+		pos := idx * pp.stepSize
+		if pp.centered {
+			pos += pp.stepSize/2 - pp.winSize/2
+		}
+		if pos+pp.winSize > len(x) {
+			break
+		}
+		c, prob := pp.candidates(x, pos+pp.winSize/2, d)
+		cands = append(cands, c)
+		probs = append(probs, prob)
+	}
+
+	// Viterbi search.
+	n := len(cands)
+	pp.frames = make([]*narray.NArray, n, n)
+	if n == 0 {
+		return nil
+	}
+	cost := make([][]float64, n, n)
+	back := make([][]int, n, n)
+	for t := range cands {
+		cost[t] = make([]float64, len(cands[t]), len(cands[t]))
+		back[t] = make([]int, len(cands[t]), len(cands[t]))
+		for j, cj := range cands[t] {
+			if t == 0 {
+				cost[t][j] = cj.cost
+				continue
+			}
+			best := math.Inf(1)
+			for i, ci := range cands[t-1] {
+				c := cost[t-1][i] + pp.transition(ci, cj)
+				if c < best {
+					best = c
+					back[t][j] = i
+				}
+			}
+			cost[t][j] = best + cj.cost
+		}
+	}
+	j := 0
+	for i, c := range cost[n-1] {
+		if c < cost[n-1][j] {
+			j = i
+		}
+	}
+	for t := n - 1; t >= 0; t-- {
+		v := narray.New(2)
+		if lag := cands[t][j].lag; lag > 0 {
+			v.Data[0] = pp.fs / lag
+		}
+		v.Data[1] = probs[t]
+		pp.frames[t] = v
+		j = back[t][j]
+	}
+	return nil
+}
+
+func (pp *PitchProc) transition(from, to pitchCandidate) float64 {
+	switch {
+	case from.lag == 0 && to.lag == 0:
+		return 0
+	case from.lag == 0 || to.lag == 0:
+		return pp.VoicingCost
+	default:
+		return pp.OctaveCost * math.Abs(math.Log2(from.lag/to.lag))
+	}
+}
+
+// candidates returns the F0 candidates for the frame centered at sample c and the voicing probability.
+// The first candidate is unvoiced. Samples outside the waveform are zero.
+func (pp *PitchProc) candidates(x []float64, c int, d []float64) ([]pitchCandidate, float64) {
+
+	// Integration window.
+	w := pp.maxLag
+	if pp.winSize > w {
+		w = pp.winSize
+	}
+	start := c - (w+pp.maxLag)/2
+	sample := func(i int) float64 {
+		if i < 0 || i >= len(x) {
+			return 0
+		}
+		return x[i]
+	}
+
+	// Cumulative mean normalized difference function.
+	var sum float64
+	d[0] = 1
+	for tau := 1; tau <= pp.maxLag+1; tau++ {
+		var s float64
+		for j := 0; j < w; j++ {
+			diff := sample(start+j) - sample(start+j+tau)
+			s += diff * diff
+		}
+		sum += s
+		if sum > 0 {
+			d[tau] = s * float64(tau) / sum
+		} else {
+			d[tau] = 1
+		}
+	}
+
+	cands := []pitchCandidate{{0, pp.Threshold}}
+	minCost := 1.0
+	for tau := pp.minLag; tau <= pp.maxLag; tau++ {
+		if d[tau] > d[tau-1] || d[tau] > d[tau+1] {
+			continue
+		}
+		// Parabolic interpolation.
+		lag := float64(tau)
+		den := d[tau-1] - 2*d[tau] + d[tau+1]
+		if den > 0 {
+			lag += 0.5 * (d[tau-1] - d[tau+1]) / den
+		}
+		cands = append(cands, pitchCandidate{lag, d[tau]})
+		minCost = math.Min(minCost, d[tau])
+	}
+
+	// Keep the best candidates.
+	voiced := cands[1:]
+	for i := 1; i < len(voiced); i++ {
+		for j := i; j > 0 && voiced[j].cost < voiced[j-1].cost; j-- {
+			voiced[j], voiced[j-1] = voiced[j-1], voiced[j]
+		}
+	}
+	if len(voiced) > pp.NumCandidates {
+		cands = cands[:pp.NumCandidates+1]
+	}
+	return cands, math.Max(0, math.Min(1, 1-minCost))
+}
@@ -0,0 +1,79 @@
+package proc
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/akualab/dsp"
+	narray "github.com/akualab/narray/na64"
+)
+
+func TestPitchProc(t *testing.T) {
+
+	fs := 16000.0
+	f0 := 150.0
+	r := rand.New(rand.NewSource(11))
+	x := make([]float64, 16000)
+	for i := range x {
+		x[i] = 0.01 * r.NormFloat64()
+		// Voiced signal between 0.3 and 0.8 seconds.
+		if i >= 4800 && i < 12800 {
+			for h := 1; h <= 5; h++ {
+				x[i] += math.Sin(2*math.Pi*f0*float64(h*i)/fs) / float64(h)
+			}
+		}
+	}
+
+	app := dsp.NewApp("pitch")
+	pitch := app.Chain(
+		app.Add("pitch", NewPitchProc(fs, DefaultMinF0, DefaultMaxF0, 160, 400, true)),
+		app.Add("wav", wavSP(x)),
+	)
+	win := app.Chain(
+		app.Add("window", NewWindowProc(160, 400, Hamming, true)),
+		app.NodeByName("wav"),
+	)
+
+	var n int
+	for ; ; n++ {
+		v, err := pitch.Get(n)
+		if err == dsp.ErrOOB {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data := v.(*narray.NArray).Data
+		if len(data) != 2 {
+			t.Fatalf("expected 2 values, got %d", len(data))
+		}
+		if data[1] < 0 || data[1] > 1 {
+			t.Fatalf("frame %d: voicing probability out of range: %f", n, data[1])
+		}
+		center := n*160 + 80
+		switch {
+		case center > 5200 && center < 12400:
+			if math.Abs(data[0]-f0) > 1 {
+				t.Fatalf("frame %d: expected F0 %f, got %f", n, f0, data[0])
+			}
+			if data[1] < 0.8 {
+				t.Fatalf("frame %d: expected high voicing probability, got %f", n, data[1])
+			}
+		case center < 4400 || center > 13200:
+			if data[0] != 0 {
+				t.Fatalf("frame %d: expected unvoiced frame, got F0 %f", n, data[0])
+			}
+		}
+	}
+	if _, err := win.Get(n - 1); err != nil {
+		t.Fatalf("expected window frame %d, got error %v", n-1, err)
+	}
+	if _, err := win.Get(n); err != dsp.ErrOOB {
+		t.Fatalf("expected %d window frames, got more", n)
+	}
+
+	if _, err := NewPitchProc(fs, 400, 60, 160, 400, true).Get(0); err == nil {
+		t.Fatal("expected error for bad F0 range")
+	}
+}
@@ -67,10 +67,18 @@ func Sub() dsp.Processer {
 	})
 }
 
-// Join stacks multiple input vectors into a single vector. Output vector size equals sum of input vector sizes.
+// JoinProc stacks multiple input vectors into a single vector. Output vector size equals sum of input vector sizes.
 // Blocks until all input vectors are available.
-func Join() dsp.Processer {
-	return dsp.NewProc(defaultBufSize, func(idx int, in ...dsp.Processer) (dsp.Value, error) {
+// The layout of the output has a range for each input named after the input node. It is derived from the
+// layouts of the inputs, which must be known. (For example, set using dsp.App.SetLayout. See dsp.App.Layout.)
+type JoinProc struct {
+	*dsp.Proc
+}
+
+// Join returns a new join processor.
+func Join() *JoinProc {
+	jp := &JoinProc{}
+	jp.Proc = dsp.NewProc(defaultBufSize, func(idx int, in ...dsp.Processer) (dsp.Value, error) {
 		numInputs := len(in)
 		framers, err := dsp.Processers(in).CheckInputs(numInputs)
 		if err != nil {
@@ -87,20 +95,56 @@ func Join() dsp.Processer {
 		na := narray.NewArray(v, len(v))
 		return na, nil
 	})
+	return jp
+}
+
+// Layout implements the dsp.Layouter interface. The range for each input has the element
+// names of the input layout. Returns an error if the layout of an input is not known.
+func (jp *JoinProc) Layout(inputs []string, layouts []dsp.Layout) (dsp.Layout, error) {
+	if len(inputs) != len(layouts) {
+		return nil, fmt.Errorf("expected %d input layouts, got %d", len(inputs), len(layouts))
+	}
+	l := make(dsp.Layout, len(inputs), len(inputs))
+	start := 0
+	for i, name := range inputs {
+		if layouts[i] == nil {
+			return nil, fmt.Errorf("layout of input [%s] is not known", name)
+		}
+		dim := layouts[i].Dim()
+		l[i] = dsp.Range{Name: name, Start: start, End: start + dim, Names: elementNames(layouts[i])}
+		start += dim
+	}
+	return l, nil
+}
+
+// elementNames returns the names of the elements in a layout. Returns nil if
+// some elements have no name.
+func elementNames(l dsp.Layout) []string {
+	var names []string
+	for _, r := range l {
+		if len(r.Names) != r.Len() {
+			return nil
+		}
+		names = append(names, r.Names...)
+	}
+	return names
 }
 
 // SpectralEnergy computes the real FFT energy of the input frame.
 // FFT size is 2^(logSize+1) and the size of the output vector is 2^logSize.
-// See dsp.RealFT and dsp.DFTEnergy for details.
+// See dsp.RealFT and dsp.DFTEnergy for details. To get the complex spectrum, use STFT.
 func SpectralEnergy(logSize int) dsp.Processer {
 	fs := 1 << uint(logSize) // output frame size
 	dftSize := 2 * fs
+	dft := make([]float64, dftSize, dftSize) // reused for all frames
 	return dsp.NewProc(defaultBufSize, func(idx int, in ...dsp.Processer) (dsp.Value, error) {
-		dft := make([]float64, dftSize, dftSize) // TODO: do not allocate every time. use slice pool?
 		vec, err := dsp.Processers(in).Get(idx)
 		if err != nil {
 			return nil, err
 		}
+		for i := range dft {
+			dft[i] = 0
+		}
 		copy(dft, vec.(*narray.NArray).Data) // zero padded
 		RealFT(dft, dftSize, true)
 		egy := DFTEnergy(dft)
@@ -127,6 +171,7 @@ func Filterbank(indices []int, coeff [][]float64) dsp.Processer {
 }
 
 // Log returns the natural logarithm of the input.
+// Zero values produce -Inf, use RobustLog to avoid them.
 func Log() dsp.Processer {
 	return dsp.NewProc(defaultBufSize, func(idx int, in ...dsp.Processer) (dsp.Value, error) {
 		vec, err := dsp.Processers(in).Get(idx)
@@ -137,6 +182,28 @@ func Log() dsp.Processer {
 	})
 }
 
+// RobustLog returns the natural logarithm of the input after adding epsilon and
+// applying a floor:
+//
+//	y[i] = log(max(x[i] + epsilon, floor))
+//
+// Use a positive floor or epsilon to avoid -Inf values for zero inputs, for example, the
+// energy of digital silence. Kaldi uses floor=1.19e-7 (float32 epsilon) and epsilon=0.
+func RobustLog(floor, epsilon float64) dsp.Processer {
+	return dsp.NewProc(defaultBufSize, func(idx int, in ...dsp.Processer) (dsp.Value, error) {
+		vec, err := dsp.Processers(in).Get(idx)
+		if err != nil {
+			return nil, err
+		}
+		x := vec.(*narray.NArray).Data
+		y := narray.New(len(x))
+		for i, v := range x {
+			y.Data[i] = math.Log(math.Max(v+epsilon, floor))
+		}
+		return y, nil
+	})
+}
+
 // Sum returns the sum of the elements of the input frame.
 func Sum() dsp.Processer {
 	return dsp.NewProc(defaultBufSize, func(idx int, in ...dsp.Processer) (dsp.Value, error) {
@@ -181,7 +248,9 @@ func MaxNorm(bufSize int, alpha float64) dsp.Processer {
 	})
 }
 
-// DCT returns the Discrete Cosine Transform of the input vector.
+// DCT returns the Discrete Cosine Transform of the input vector using the matrix
+// generated by GenerateDCT. The first coefficient (c0) is not included in the output.
+// Use TypedDCT for the standard DCT definitions, orthonormal scaling, and to keep c0.
 func DCT(inSize, outSize int) dsp.Processer {
 
 	dct := GenerateDCT(outSize+1, inSize)
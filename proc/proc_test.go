@@ -7,8 +7,10 @@ package proc
 
 import (
 	"fmt"
+	"math"
 	"math/rand"
 	"os"
+	"reflect"
 	"testing"
 
 	"github.com/akualab/dsp"
@@ -91,6 +93,24 @@ func TestJoin(t *testing.T) {
 	join := app.Add("join", Join())
 	app.Connect(join, s1, s2)
 	out := join
+	if _, err := app.Layout("join"); err == nil {
+		t.Fatal("expected error for unknown input layouts")
+	}
+	if err := app.SetLayout("s1", dsp.Layout{{Name: "a", Start: 0, End: dim}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.SetLayout("s2", dsp.Layout{{Name: "b", Start: 0, End: dim, Names: []string{"w", "x", "y", "z"}}}); err != nil {
+		t.Fatal(err)
+	}
+	// The layout is known before processing.
+	layout, err := app.Layout("join")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(layout) != 2 || layout[1].Name != "s2" || layout[1].Start != dim || layout.Dim() != 2*dim ||
+		layout[0].Names != nil || layout[1].Names[3] != "z" {
+		t.Fatalf("unexpected layout: %v", layout)
+	}
 	for k := 0; k < 2; k++ {
 		var i int
 		for ; i < 20; i++ {
@@ -110,6 +130,10 @@ func TestJoin(t *testing.T) {
 			}
 		}
 	}
+	app.Reset()
+	if l, err := app.Layout("join"); err != nil || !reflect.DeepEqual(l, layout) {
+		t.Fatalf("unexpected layout after reset: %v, error: %v", l, err)
+	}
 }
 
 func TestMovingAverage(t *testing.T) {
@@ -136,7 +160,7 @@ func TestMovingAverage(t *testing.T) {
 		v := val.(*narray.NArray)
 		t.Log(i, v)
 		if v.Data[0] != expected[i] {
-			t.Fatalf("expected %f, got %f", expected[i], v)
+			t.Fatalf("expected %f, got %f", expected[i], v.Data[0])
 		}
 	}
 	app.Reset()
@@ -181,3 +205,26 @@ func TestDiff(t *testing.T) {
 	}
 	app.Reset()
 }
+
+func TestRobustLog(t *testing.T) {
+
+	x := [][]float64{{0, 1e-20, 1, math.E}}
+	for _, c := range []struct {
+		floor, epsilon float64
+		expected       []float64
+	}{
+		{1e-10, 0, []float64{math.Log(1e-10), math.Log(1e-10), 0, 1}},
+		{0, 1e-10, []float64{math.Log(1e-10), math.Log(1e-10 + 1e-20), math.Log(1 + 1e-10), math.Log(math.E + 1e-10)}},
+	} {
+		app := dsp.NewApp("log")
+		out := app.Chain(
+			app.Add("log", RobustLog(c.floor, c.epsilon)),
+			app.Add("frames", frames(x)),
+		)
+		v, err := out.Get(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		compareSliceFloat(t, c.expected, v.(*narray.NArray).Data, "robust log", 1e-12)
+	}
+}
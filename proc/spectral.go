@@ -0,0 +1,138 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package proc
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+
+	"github.com/akualab/dsp"
+	narray "github.com/akualab/narray/na64"
+)
+
+// STFT computes the short-time Fourier transform of the input frames. The input is typically
+// the output of a WindowProc. Frames shorter than fftSize are zero padded.
+// The output value is of type []complex128 and has the fftSize/2+1 non-negative frequency components.
+// The FFT plan is created once and reused for all frames. (See FFT for details.)
+func STFT(fftSize int) dsp.Processer {
+	plan, err := NewFFT(fftSize, NormBackward)
+	return dsp.NewProc(defaultBufSize, func(idx int, in ...dsp.Processer) (dsp.Value, error) {
+		if err != nil {
+			return nil, err
+		}
+		vec, e := dsp.Processers(in).Get(idx)
+		if e != nil {
+			return nil, e
+		}
+		data := vec.(*narray.NArray).Data
+		if len(data) > fftSize {
+			return nil, fmt.Errorf("frame size [%d] is larger than FFT size [%d]", len(data), fftSize)
+		}
+		return plan.ForwardReal(nil, data), nil
+	})
+}
+
+// complexFrame returns the complex spectrum from input 0.
+func complexFrame(idx int, in []dsp.Processer) ([]complex128, error) {
+	vec, err := dsp.Processers(in).Get(idx)
+	if err != nil {
+		return nil, err
+	}
+	spec, ok := vec.([]complex128)
+	if !ok {
+		return nil, fmt.Errorf("expected input value of type []complex128, got %T", vec)
+	}
+	return spec, nil
+}
+
+// spectralProc returns a processor that applies f to each component of a complex spectrum.
+func spectralProc(f func(complex128) float64) dsp.Processer {
+	return dsp.NewProc(defaultBufSize, func(idx int, in ...dsp.Processer) (dsp.Value, error) {
+		spec, err := complexFrame(idx, in)
+		if err != nil {
+			return nil, err
+		}
+		v := narray.New(len(spec))
+		for k, c := range spec {
+			v.Data[k] = f(c)
+		}
+		return v, nil
+	})
+}
+
+// Magnitude returns the magnitude of the complex spectrum. The input is the output of STFT.
+func Magnitude() dsp.Processer {
+	return spectralProc(cmplx.Abs)
+}
+
+// Power returns the squared magnitude of the complex spectrum. The input is the output of STFT.
+func Power() dsp.Processer {
+	return spectralProc(func(c complex128) float64 {
+		return real(c)*real(c) + imag(c)*imag(c)
+	})
+}
+
+// Phase returns the phase of the complex spectrum in radians in the range [-Pi, Pi].
+// The input is the output of STFT.
+func Phase() dsp.Processer {
+	return spectralProc(cmplx.Phase)
+}
+
+// LogMagnitude returns the natural logarithm of the magnitude of the complex spectrum.
+// Magnitudes less than floor are replaced with floor to avoid -Inf values.
+// The input is the output of STFT.
+func LogMagnitude(floor float64) dsp.Processer {
+	return spectralProc(func(c complex128) float64 {
+		return math.Log(math.Max(cmplx.Abs(c), floor))
+	})
+}
+
+/*
+GroupDelay computes the group delay in samples of the input frames. The input is typically
+the output of a WindowProc. The group delay is computed without phase unwrapping as follows:
+
+	         Re{X[k]} Re{Y[k]} + Im{X[k]} Im{Y[k]}
+	tau[k] = -------------------------------------
+	                     |X[k]|^2
+
+where X is the DFT of x[n] and Y is the DFT of n*x[n]. Output has fftSize/2+1 values.
+Components whose energy is less than floor are set to zero.
+*/
+func GroupDelay(fftSize int, floor float64) dsp.Processer {
+	plan, err := NewFFT(fftSize, NormBackward)
+	nx := make([]float64, fftSize, fftSize)
+	return dsp.NewProc(defaultBufSize, func(idx int, in ...dsp.Processer) (dsp.Value, error) {
+		if err != nil {
+			return nil, err
+		}
+		vec, e := dsp.Processers(in).Get(idx)
+		if e != nil {
+			return nil, e
+		}
+		data := vec.(*narray.NArray).Data
+		if len(data) > fftSize {
+			return nil, fmt.Errorf("frame size [%d] is larger than FFT size [%d]", len(data), fftSize)
+		}
+		for i := range nx {
+			nx[i] = 0
+		}
+		for i, v := range data {
+			nx[i] = float64(i) * v
+		}
+		x := plan.ForwardReal(nil, data)
+		y := plan.ForwardReal(nil, nx)
+		v := narray.New(len(x))
+		for k := range x {
+			egy := real(x[k])*real(x[k]) + imag(x[k])*imag(x[k])
+			if egy < floor {
+				continue
+			}
+			v.Data[k] = (real(x[k])*real(y[k]) + imag(x[k])*imag(y[k])) / egy
+		}
+		return v, nil
+	})
+}
@@ -0,0 +1,78 @@
+package proc
+
+import (
+	"math"
+	"testing"
+
+	"github.com/akualab/dsp"
+	narray "github.com/akualab/narray/na64"
+)
+
+func frames(data [][]float64) dsp.Processer {
+	return dsp.NewProc(len(data), func(idx int, in ...dsp.Processer) (dsp.Value, error) {
+		if idx < 0 || idx >= len(data) {
+			return nil, dsp.ErrOOB
+		}
+		return narray.NewArray(data[idx], len(data[idx])), nil
+	})
+}
+
+func TestSTFT(t *testing.T) {
+
+	data := []float64{0.5, 1.0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	app := dsp.NewApp("stft")
+	src := app.Add("frames", frames([][]float64{data, data}))
+	stft := app.Connect(app.Add("stft", STFT(16)), src)
+	egy := app.Connect(app.Add("spectrum", SpectralEnergy(3)), src)
+	pow := app.Connect(app.Add("power", Power()), stft)
+	mag := app.Connect(app.Add("magnitude", Magnitude()), stft)
+	logMag := app.Connect(app.Add("log magnitude", LogMagnitude(1e-10)), stft)
+	phase := app.Connect(app.Add("phase", Phase()), stft)
+
+	for i := 0; i < 2; i++ {
+		v, err := stft.Get(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(v.([]complex128)) != 9 {
+			t.Fatalf("expected 9 values, got %d", len(v.([]complex128)))
+		}
+		e, _ := egy.Get(i)
+		p, _ := pow.Get(i)
+		m, _ := mag.Get(i)
+		lm, _ := logMag.Get(i)
+		ph, _ := phase.Get(i)
+		ev := e.(*narray.NArray).Data
+		pv := p.(*narray.NArray).Data
+		mv := m.(*narray.NArray).Data
+		compareSliceFloat(t, ev, pv[:8], "power", 1e-12)
+		for k := range pv {
+			compareFloats(t, pv[k], mv[k]*mv[k], "magnitude", 1e-12)
+			compareFloats(t, math.Log(mv[k]), lm.(*narray.NArray).Data[k], "log magnitude", 1e-12)
+		}
+		// X[k] = 0.5 + exp(-i w k), phase at k=4 is atan2(-1, 0.5).
+		compareFloats(t, math.Atan2(-1, 0.5), ph.(*narray.NArray).Data[4], "phase", 1e-12)
+	}
+	if _, err := stft.Get(2); err != dsp.ErrOOB {
+		t.Fatalf("expected ErrOOB, got %v", err)
+	}
+}
+
+func TestGroupDelay(t *testing.T) {
+
+	// A delayed impulse has a constant group delay.
+	data := make([]float64, 20)
+	data[5] = 1
+	app := dsp.NewApp("group delay")
+	gd := app.Chain(
+		app.Add("group delay", GroupDelay(32, 1e-10)),
+		app.Add("frames", frames([][]float64{data})),
+	)
+	v, err := gd.Get(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range v.(*narray.NArray).Data {
+		compareFloats(t, 5, d, "group delay", 1e-9)
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package spectrogram
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+)
+
+// Colormap maps a value in the range [0,1] to a color.
+type Colormap func(v float64) color.RGBA
+
+// Predefined colormaps.
+var (
+	// Gray maps low values to black and high values to white.
+	Gray = linearColormap(color.RGBA{0, 0, 0, 255}, color.RGBA{255, 255, 255, 255})
+	// InvGray maps low values to white and high values to black.
+	InvGray = linearColormap(color.RGBA{255, 255, 255, 255}, color.RGBA{0, 0, 0, 255})
+	// Hot goes from black to red, yellow and white.
+	Hot = linearColormap(
+		color.RGBA{0, 0, 0, 255}, color.RGBA{230, 0, 0, 255},
+		color.RGBA{255, 210, 0, 255}, color.RGBA{255, 255, 255, 255})
+	// Jet goes from dark blue to blue, cyan, yellow, red and dark red.
+	Jet = linearColormap(
+		color.RGBA{0, 0, 128, 255}, color.RGBA{0, 0, 255, 255}, color.RGBA{0, 255, 255, 255},
+		color.RGBA{255, 255, 0, 255}, color.RGBA{255, 0, 0, 255}, color.RGBA{128, 0, 0, 255})
+	// Viridis is an approximation of the perceptually uniform colormap used in matplotlib.
+	Viridis = linearColormap(
+		color.RGBA{68, 1, 84, 255}, color.RGBA{59, 82, 139, 255}, color.RGBA{33, 145, 140, 255},
+		color.RGBA{94, 201, 98, 255}, color.RGBA{253, 231, 37, 255})
+)
+
+var colormaps = map[string]Colormap{
+	"gray":    Gray,
+	"invgray": InvGray,
+	"hot":     Hot,
+	"jet":     Jet,
+	"viridis": Viridis,
+}
+
+// ColormapByName returns a predefined colormap. The names are "gray", "invgray", "hot", "jet" and "viridis".
+func ColormapByName(name string) (Colormap, error) {
+	cm, ok := colormaps[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown colormap: %s", name)
+	}
+	return cm, nil
+}
+
+// linearColormap returns a colormap that interpolates linearly between equally spaced colors.
+func linearColormap(stops ...color.RGBA) Colormap {
+	return func(v float64) color.RGBA {
+		if math.IsNaN(v) {
+			v = 0
+		}
+		v = math.Max(0, math.Min(1, v))
+		x := v * float64(len(stops)-1)
+		i := int(x)
+		if i >= len(stops)-1 {
+			return stops[len(stops)-1]
+		}
+		f := x - float64(i)
+		mix := func(a, b uint8) uint8 {
+			return uint8(math.Floor(float64(a)*(1-f) + float64(b)*f + 0.5))
+		}
+		a, b := stops[i], stops[i+1]
+		return color.RGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), 255}
+	}
+}
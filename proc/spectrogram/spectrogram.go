@@ -0,0 +1,292 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package spectrogram renders the output of a processor over a whole stream as an image. Frames are
+drawn as columns from left to right, the first element of the vectors is at the bottom. Only the
+standard library image packages are used.
+
+For example, to render the log filterbank of the speech app with the energy as an overlay:
+
+	err := spectrogram.WritePNGFile("utt1.png", app.NodeByName("log filterbank"), spectrogram.Config{
+	  Colormap: spectrogram.Viridis,
+	  Overlays: []spectrogram.Overlay{{Input: app.NodeByName("normalized cepstral energy")}},
+	})
+
+Call the function after processing a stream and before calling dsp.App.Reset.
+*/
+package spectrogram
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"os"
+
+	"github.com/akualab/dsp"
+	narray "github.com/akualab/narray/na64"
+)
+
+// Overlay is a line drawn on top of the image.
+type Overlay struct {
+	// Input is the processor that has the values. The values must be vectors.
+	Input dsp.Framer
+	// Index is the element of the vectors that is drawn.
+	Index int
+	// Color of the line. Default is white.
+	Color color.RGBA
+	// Min and Max are the values that map to the bottom and top of the image. If both are zero,
+	// the range of the values is used.
+	Min, Max float64
+}
+
+// Config has the rendering parameters.
+type Config struct {
+	// Colormap maps normalized values to colors. Default is Gray.
+	Colormap Colormap
+	// DB converts the values to decibels (10 log10) before rendering. Use for power spectra.
+	DB bool
+	// DynamicRange is the range of values that are rendered, values lower than the max value
+	// minus DynamicRange are clipped. Use zero to render the full range.
+	DynamicRange float64
+	// FS is the sampling rate. If positive, a frequency axis with a tick every kHz (longer ticks every
+	// 5 kHz) is drawn on the left side assuming that the vector elements are equally spaced between
+	// zero and FS/2, as in SpectralEnergy.
+	FS float64
+	// Freqs has the frequency in Hertz of each vector element. Overrides the default frequency axis, for
+	// example, use proc.FilterCenters for filterbank outputs.
+	Freqs []float64
+	// PixelsPerFrame is the width of each frame in pixels. Default is 1.
+	PixelsPerFrame int
+	// PixelsPerBin is the height of each vector element in pixels. Default is 1.
+	PixelsPerBin int
+	// Overlays are lines drawn on top of the image.
+	Overlays []Overlay
+}
+
+// axisWidth is the width of the frequency axis in pixels.
+const axisWidth = 8
+
+// readStream returns all the vectors in a stream.
+func readStream(in dsp.Framer) ([][]float64, error) {
+	data := [][]float64{}
+	for i := 0; ; i++ {
+		v, err := in.Get(i)
+		if err == dsp.ErrOOB {
+			return data, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		na, ok := v.(*narray.NArray)
+		if !ok {
+			return nil, fmt.Errorf("expected values of type *narray.NArray, got %T", v)
+		}
+		// Copy, the values may be modified.
+		data = append(data, append([]float64(nil), na.Data...))
+	}
+}
+
+// Render returns an image of the output of in over the whole stream.
+// Non-finite values, such as the log of zero, are rendered using the bottom color.
+func Render(in dsp.Framer, c Config) (*image.RGBA, error) {
+
+	data, err := readStream(in)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("stream is empty")
+	}
+	if c.Colormap == nil {
+		c.Colormap = Gray
+	}
+	if c.PixelsPerFrame < 1 {
+		c.PixelsPerFrame = 1
+	}
+	if c.PixelsPerBin < 1 {
+		c.PixelsPerBin = 1
+	}
+	dim := len(data[0])
+	if c.Freqs != nil && len(c.Freqs) != dim {
+		return nil, fmt.Errorf("mismatch in number of frequencies [%d] and vector size [%d]", len(c.Freqs), dim)
+	}
+
+	// Value range.
+	max := math.Inf(-1)
+	min := math.Inf(1)
+	for t, v := range data {
+		if len(v) != dim {
+			return nil, fmt.Errorf("frame %d has size %d, expected %d", t, len(v), dim)
+		}
+		for k, x := range v {
+			if c.DB {
+				x = 10 * math.Log10(math.Max(x, 1e-30))
+				v[k] = x
+			}
+			if math.IsNaN(x) || math.IsInf(x, 0) {
+				continue
+			}
+			max = math.Max(max, x)
+			min = math.Min(min, x)
+		}
+	}
+	if c.DynamicRange > 0 {
+		min = math.Max(min, max-c.DynamicRange)
+	}
+
+	left := 0
+	if c.FS > 0 || c.Freqs != nil {
+		left = axisWidth
+	}
+	width := left + len(data)*c.PixelsPerFrame
+	height := dim * c.PixelsPerBin
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for t, v := range data {
+		for k, x := range v {
+			norm := 1.0
+			switch {
+			case math.IsNaN(x) || math.IsInf(x, 0):
+				norm = 0
+			case max > min:
+				norm = (x - min) / (max - min)
+			}
+			col := c.Colormap(norm)
+			for i := 0; i < c.PixelsPerFrame; i++ {
+				for j := 0; j < c.PixelsPerBin; j++ {
+					img.SetRGBA(left+t*c.PixelsPerFrame+i, height-1-k*c.PixelsPerBin-j, col)
+				}
+			}
+		}
+	}
+	if left > 0 {
+		drawAxis(img, c, dim, height)
+	}
+	for _, o := range c.Overlays {
+		if err := drawOverlay(img, o, left, len(data), c.PixelsPerFrame); err != nil {
+			return nil, err
+		}
+	}
+	return img, nil
+}
+
+// drawAxis draws the frequency ticks.
+func drawAxis(img *image.RGBA, c Config, dim, height int) {
+	white := color.RGBA{255, 255, 255, 255}
+	black := color.RGBA{0, 0, 0, 255}
+	for y := 0; y < height; y++ {
+		for x := 0; x < axisWidth; x++ {
+			img.SetRGBA(x, y, black)
+		}
+	}
+	freq := func(k float64) float64 {
+		if c.Freqs != nil {
+			return c.Freqs[int(k)]
+		}
+		return k * c.FS / float64(2*dim)
+	}
+	// Place a tick at the first element whose frequency reaches each multiple of 1 kHz.
+	next := 1000.0
+	for k := 0; k < dim; k++ {
+		f := freq(float64(k))
+		if f < next {
+			continue
+		}
+		length := axisWidth / 2
+		if math.Mod(math.Floor(f/1000+1e-9), 5) == 0 {
+			length = axisWidth
+		}
+		y := height - 1 - k*c.PixelsPerBin
+		for x := axisWidth - length; x < axisWidth; x++ {
+			img.SetRGBA(x, y, white)
+		}
+		next = (math.Floor(f/1000+1e-9) + 1) * 1000
+	}
+}
+
+// drawOverlay draws a line with the values of the overlay input.
+func drawOverlay(img *image.RGBA, o Overlay, left, n, ppf int) error {
+	data, err := readStream(o.Input)
+	if err != nil {
+		return err
+	}
+	if o.Color == (color.RGBA{}) {
+		o.Color = color.RGBA{255, 255, 255, 255}
+	}
+	min, max := o.Min, o.Max
+	if min == 0 && max == 0 {
+		min, max = math.Inf(1), math.Inf(-1)
+		for _, v := range data {
+			if o.Index < len(v) {
+				min = math.Min(min, v[o.Index])
+				max = math.Max(max, v[o.Index])
+			}
+		}
+	}
+	height := img.Bounds().Dy()
+	ypos := func(x float64) int {
+		if max <= min {
+			return height / 2
+		}
+		y := int(math.Floor((x - min) / (max - min) * float64(height-1)))
+		if y < 0 {
+			y = 0
+		}
+		if y > height-1 {
+			y = height - 1
+		}
+		return height - 1 - y
+	}
+	prev := -1
+	for t, v := range data {
+		if t >= n {
+			break
+		}
+		if o.Index >= len(v) {
+			return fmt.Errorf("overlay index [%d] is out of range, vector size is %d", o.Index, len(v))
+		}
+		y := ypos(v[o.Index])
+		for i := 0; i < ppf; i++ {
+			img.SetRGBA(left+t*ppf+i, y, o.Color)
+		}
+		// Connect to the previous point.
+		if prev >= 0 {
+			lo, hi := prev, y
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			for yy := lo; yy <= hi; yy++ {
+				img.SetRGBA(left+t*ppf, yy, o.Color)
+			}
+		}
+		prev = y
+	}
+	return nil
+}
+
+// WritePNG renders the output of in and writes the image in PNG format.
+func WritePNG(w io.Writer, in dsp.Framer, c Config) error {
+	img, err := Render(in, c)
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, img)
+}
+
+// WritePNGFile renders the output of in and writes the image to a PNG file.
+func WritePNGFile(path string, in dsp.Framer, c Config) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := WritePNG(f, in, c); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,122 @@
+package spectrogram
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"math"
+	"testing"
+
+	"github.com/akualab/dsp"
+	narray "github.com/akualab/narray/na64"
+)
+
+// frames returns a source for a sequence of vectors.
+func frames(data [][]float64) dsp.Processer {
+	return dsp.NewProc(100, func(idx int, in ...dsp.Processer) (dsp.Value, error) {
+		if idx < 0 || idx >= len(data) {
+			return nil, dsp.ErrOOB
+		}
+		return narray.NewArray(data[idx], len(data[idx])), nil
+	})
+}
+
+func TestColormaps(t *testing.T) {
+	if c := Gray(0); c != (color.RGBA{0, 0, 0, 255}) {
+		t.Fatalf("expected black, got %v", c)
+	}
+	if c := Gray(1); c != (color.RGBA{255, 255, 255, 255}) {
+		t.Fatalf("expected white, got %v", c)
+	}
+	if c := Gray(0.5); c.R != 128 {
+		t.Fatalf("expected mid gray, got %v", c)
+	}
+	if c := Jet(2); c != (color.RGBA{128, 0, 0, 255}) {
+		t.Fatalf("expected values to be clipped, got %v", c)
+	}
+	if _, err := ColormapByName("viridis"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ColormapByName("foo"); err == nil {
+		t.Fatal("expected error for unknown colormap")
+	}
+}
+
+func TestRender(t *testing.T) {
+
+	// 4 frames with 8 bins. Bin k has power 10^k.
+	data := make([][]float64, 4)
+	for i := range data {
+		data[i] = []float64{1, 10, 100, 1e3, 1e4, 1e5, 1e6, 1e7}
+	}
+	src := frames(data).(dsp.Framer)
+	vad := frames([][]float64{{0}, {1}, {1}, {0}}).(dsp.Framer)
+	red := color.RGBA{255, 0, 0, 255}
+	img, err := Render(src, Config{
+		DB:             true,
+		DynamicRange:   30,
+		FS:             16000,
+		PixelsPerFrame: 2,
+		PixelsPerBin:   3,
+		Overlays:       []Overlay{{Input: vad, Color: red}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := img.Bounds()
+	if b.Dx() != axisWidth+8 || b.Dy() != 24 {
+		t.Fatalf("unexpected image size %v", b)
+	}
+	// Top bin is the max value, bins below the dynamic range are black.
+	if c := img.RGBAAt(axisWidth+1, 1); c != (color.RGBA{255, 255, 255, 255}) {
+		t.Fatalf("expected white at the top, got %v", c)
+	}
+	if c := img.RGBAAt(axisWidth+1, 12); c != (color.RGBA{0, 0, 0, 255}) {
+		t.Fatalf("expected black below the dynamic range, got %v", c)
+	}
+	// Overlay: speech frames at the top, non-speech at the bottom.
+	if c := img.RGBAAt(axisWidth+2, 0); c != red {
+		t.Fatalf("expected overlay at the top, got %v", c)
+	}
+	if c := img.RGBAAt(axisWidth, 23); c != red {
+		t.Fatalf("expected overlay at the bottom, got %v", c)
+	}
+	// The source values are not modified.
+	v, _ := src.Get(0)
+	if v.(*narray.NArray).Data[1] != 10 {
+		t.Fatal("source values were modified")
+	}
+
+	var buf bytes.Buffer
+	if err := WritePNG(&buf, src, Config{Colormap: Viridis}); err != nil {
+		t.Fatal(err)
+	}
+	dec, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dec.Bounds().Dx() != 4 || dec.Bounds().Dy() != 8 {
+		t.Fatalf("unexpected decoded image size %v", dec.Bounds())
+	}
+
+	// Log of digital silence: -Inf is rendered black and does not change the range.
+	img, err = Render(frames([][]float64{{math.Inf(-1), 0, 1}, {-1, 0, 1}}).(dsp.Framer), Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, expected := range []uint8{0, 128, 255} {
+		if c := img.RGBAAt(1, 2-k); c.R != expected {
+			t.Fatalf("bin %d: expected gray level %d, got %v", k, expected, c)
+		}
+	}
+	if c := img.RGBAAt(0, 2); c != (color.RGBA{0, 0, 0, 255}) {
+		t.Fatalf("expected black for -Inf, got %v", c)
+	}
+
+	if _, err := Render(src, Config{Freqs: []float64{1, 2}}); err == nil {
+		t.Fatal("expected error for wrong number of frequencies")
+	}
+	if _, err := Render(frames(nil).(dsp.Framer), Config{}); err == nil {
+		t.Fatal("expected error for empty stream")
+	}
+}
@@ -0,0 +1,341 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package speech
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/akualab/dsp"
+	"github.com/akualab/dsp/proc"
+)
+
+// DefaultDeltaCoeff are the coefficients used for the delta features when DeltaCoeff is empty.
+var DefaultDeltaCoeff = []float64{0.7, 0.2, 0.1}
+
+/*
+SetDefaults sets the value of zero fields that have a sensible default:
+
+	BufSize      1000
+	WinSize      25 ms
+	WinStep      10 ms
+	LogFFTSize   smallest FFT that fits the window (when FFTSize is zero)
+	FFTSize      2^(LogFFTSize+1) (for LogMelFeatures, which use the mel filterbank computed from STFT)
+	FBSize       23
+	FBMaxFreq    FS/2
+	CepSize      12
+	PLPOrder     12
+	DeltaCoeff   DefaultDeltaCoeff
+	MinF0        proc.DefaultMinF0
+	MaxF0        proc.DefaultMaxF0
+	FBScale      proc.MelHTK
+	DeltaWindow  2 (when DeltaOrder is set)
+	LogFloor     DefaultLogFloor (use a negative value for no floor)
+	EnergyFloor  DefaultLogFloor (use a negative value for no floor)
+	Features     DefaultFeatures or DefaultLogMelFeatures, depending on FeatureType
+
+The sampling rate FS has no default. Fields that depend on FS are not changed when FS is zero.
+*/
+func (c *Config) SetDefaults() {
+
+	if c.BufSize == 0 {
+		c.BufSize = defaultBufSize
+	}
+	if c.FS > 0 {
+		if c.WinSize == 0 {
+			c.WinSize = int(math.Round(0.025 * c.FS))
+		}
+		if c.WinStep == 0 {
+			c.WinStep = int(math.Round(0.010 * c.FS))
+		}
+		if c.FBMaxFreq == 0 {
+			c.FBMaxFreq = c.FS / 2
+		}
+	}
+	if c.LogFFTSize == 0 && c.FFTSize == 0 {
+		for 2<<uint(c.LogFFTSize) < c.WinSize {
+			c.LogFFTSize++
+		}
+	}
+	if c.FeatureType == LogMelFeatures && c.FFTSize == 0 {
+		c.FFTSize = 2 << uint(c.LogFFTSize)
+	}
+	if c.FBSize == 0 {
+		c.FBSize = 23
+	}
+	if c.CepSize == 0 {
+		c.CepSize = 12
+	}
+	if c.PLPOrder == 0 {
+		c.PLPOrder = 12
+	}
+	if len(c.DeltaCoeff) == 0 {
+		c.DeltaCoeff = DefaultDeltaCoeff
+	}
+	if c.MinF0 == 0 {
+		c.MinF0 = proc.DefaultMinF0
+	}
+	if c.MaxF0 == 0 {
+		c.MaxF0 = proc.DefaultMaxF0
+	}
+	if c.FBScale == nil {
+		c.FBScale = proc.MelHTK
+	}
+	if c.DeltaOrder > 0 && c.DeltaWindow == 0 {
+		c.DeltaWindow = 2
+	}
+	if c.LogFloor == 0 {
+		c.LogFloor = DefaultLogFloor
+	}
+	if c.EnergyFloor == 0 {
+		c.EnergyFloor = DefaultLogFloor
+	}
+	if len(c.Features) == 0 {
+		switch {
+		case c.FeatureType == LogMelFeatures && c.Energy:
+			c.Features = append([]string{"log energy"}, DefaultLogMelFeatures...)
+		case c.FeatureType == LogMelFeatures:
+			c.Features = DefaultLogMelFeatures
+		default:
+			c.Features = DefaultFeatures
+		}
+	}
+}
+
+// Validate returns an error if the configuration is not valid. Zero fields are not
+// replaced with defaults, call SetDefaults first. (New does both.)
+func (c Config) Validate() error {
+
+	switch {
+	case c.FS <= 0:
+		return fmt.Errorf("sampling rate must be positive, got %f", c.FS)
+	case c.BufSize <= 0:
+		return fmt.Errorf("buffer size must be positive, got %d", c.BufSize)
+	case c.WinSize <= 0 || c.WinStep <= 0:
+		return fmt.Errorf("window size and step must be positive, got size:%d, step:%d", c.WinSize, c.WinStep)
+	case c.PreEmphasis < 0 || c.PreEmphasis >= 1:
+		return fmt.Errorf("pre-emphasis coefficient must be in [0, 1), got %f", c.PreEmphasis)
+	case c.Framing < CenteredFrames || c.Framing > PaddedFrames:
+		return fmt.Errorf("unknown framing mode: %d", c.Framing)
+	case c.FeatureType < CepstrumFeatures || c.FeatureType > LogMelFeatures:
+		return fmt.Errorf("unknown feature type: %d", c.FeatureType)
+	case c.FFTSize < 0 || c.LogFFTSize < 0:
+		return fmt.Errorf("FFT size must not be negative, got FFTSize:%d, LogFFTSize:%d", c.FFTSize, c.LogFFTSize)
+	case c.FBSize <= 0 || c.CepSize <= 0:
+		return fmt.Errorf("filterbank and cepstrum sizes must be positive, got FBSize:%d, CepSize:%d", c.FBSize, c.CepSize)
+	case c.FBMinFreq < 0 || c.FBMaxFreq <= c.FBMinFreq || c.FBMaxFreq > c.FS/2:
+		return fmt.Errorf("filterbank frequencies must satisfy 0 <= min < max <= fs/2, got fs:%f, min:%f, max:%f",
+			c.FS, c.FBMinFreq, c.FBMaxFreq)
+	case c.PLPOrder <= 0:
+		return fmt.Errorf("PLP order must be positive, got %d", c.PLPOrder)
+	case c.MinF0 <= 0 || c.MaxF0 <= c.MinF0 || c.MaxF0 >= c.FS/2:
+		return fmt.Errorf("pitch range must satisfy 0 < min < max < fs/2, got fs:%f, min:%f, max:%f",
+			c.FS, c.MinF0, c.MaxF0)
+	case c.VADHangover < 0 || c.VADMinSpeech < 0:
+		return fmt.Errorf("VAD hangover and min speech must not be negative, got hangover:%d, min speech:%d",
+			c.VADHangover, c.VADMinSpeech)
+	case c.CepLifter < 0 || c.LogEpsilon < 0 || c.Dither < 0:
+		return fmt.Errorf("lifter, epsilon and dither must not be negative, got lifter:%f, log epsilon:%g, dither:%g",
+			c.CepLifter, c.LogEpsilon, c.Dither)
+	case c.DeltaOrder < 0 || (c.DeltaOrder > 0 && c.DeltaWindow <= 0):
+		return fmt.Errorf("regression deltas require a non-negative order and a positive window, got order:%d, window:%d",
+			c.DeltaOrder, c.DeltaWindow)
+	case len(c.Features) == 0:
+		return fmt.Errorf("no features selected")
+	}
+
+	if _, err := proc.WindowSlice(c.WinType, c.WinSize); err != nil {
+		return err
+	}
+
+	// Spectrum and filterbank.
+	fftSize := c.fftSize()
+	if c.WinSize > fftSize {
+		return fmt.Errorf("window size [%d] is larger than FFT size [%d]", c.WinSize, fftSize)
+	}
+	if c.FFTSize > 0 {
+		if c.FBScale == nil {
+			return fmt.Errorf("filterbank frequency scale is not set")
+		}
+		if _, _, err := proc.GenerateScaleFilterbank(c.FFTSize/2, c.FBSize, c.FS, c.FBMinFreq, c.FBMaxFreq, c.FBScale, c.FBFlags); err != nil {
+			return err
+		}
+	} else {
+		n := 1 << uint(c.LogFFTSize)
+		num := int(float64(n)*c.FBMaxFreq/(c.FS/2)) - int(float64(n)*c.FBMinFreq/(c.FS/2))
+		if num < c.FBSize {
+			return fmt.Errorf("not enough DFT points [%d] in the frequency range for %d filters, increase the FFT size", num, c.FBSize)
+		}
+	}
+
+	// Cepstrum.
+	numCoeff := c.CepSize
+	if c.FFTSize == 0 || !c.KeepC0 {
+		numCoeff++
+	}
+	if c.builds(cepstrumNodes...) && numCoeff > c.FBSize {
+		return fmt.Errorf("too many cepstral coefficients [%d] for %d filters", c.CepSize, c.FBSize)
+	}
+
+	// Make sure all the features have known dimensions.
+	for _, name := range c.Features {
+		if _, err := c.dimNames(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var (
+	// cepstrumNodes are computed from the cepstrum.
+	cepstrumNodes = []string{"cepstrum", "unliftered cepstrum", "mean cepstrum", "zm cepstrum", "cmvn cepstrum",
+		"delta cepstrum", "delta delta cepstrum"}
+	// energyNodes are computed from the sum of the log filterbank.
+	energyNodes = []string{"cepstral energy", "max cepstral energy", "normalized cepstral energy",
+		"delta energy", "delta delta energy"}
+)
+
+// builds returns true if the nodes are added to the graph. All the nodes are added for
+// CepstrumFeatures. For other feature types, the nodes are only added if one of them is
+// selected in Features.
+func (c Config) builds(nodes ...string) bool {
+	if c.FeatureType == CepstrumFeatures {
+		return true
+	}
+	for _, f := range c.Features {
+		for _, n := range nodes {
+			if f == n {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// fftSize returns the size of the FFT.
+func (c Config) fftSize() int {
+	if c.FFTSize > 0 {
+		return c.FFTSize
+	}
+	return 2 << uint(c.LogFFTSize)
+}
+
+// Derived has parameters derived from a configuration. (See Config.Derived.)
+type Derived struct {
+	// FFT size in samples.
+	FFTSize int `json:"fft_size"`
+	// Number of frames per second.
+	FrameRate float64 `json:"frame_rate"`
+	// Frequency resolution of the spectrum in Hertz.
+	FreqResolution float64 `json:"freq_resolution"`
+	// Dimension of the "combined" feature vector.
+	Dim int `json:"dim"`
+	// Name of each element of the "combined" feature vector.
+	Names []string `json:"names"`
+	// Layout of the "combined" feature vector.
+	Layout dsp.Layout `json:"layout"`
+}
+
+// String returns a summary of the derived parameters for logging.
+func (d Derived) String() string {
+	return fmt.Sprintf("fft size: %d, frame rate: %.2f Hz, freq resolution: %.2f Hz, dim: %d, names: [%s]",
+		d.FFTSize, d.FrameRate, d.FreqResolution, d.Dim, strings.Join(d.Names, " "))
+}
+
+/*
+Derived returns the parameters derived from the configuration after setting defaults.
+Returns an error if the configuration is not valid.
+
+Each element of the feature vector is named using the node name followed by a label in brackets,
+for example, "cepstrum[c1]", "filterbank[3]" or "pitch[f0]". Nodes with a single value use the node
+name, for example, "log energy". Regression deltas append the order, for example, "cepstrum[c1]_d1".
+The layout has a range for each feature node and for each order of the regression deltas of each
+feature node, for example, "cepstrum_d1". (New sets this layout on the "combined" node.)
+*/
+func (c Config) Derived() (Derived, error) {
+
+	c.SetDefaults()
+	if err := c.Validate(); err != nil {
+		return Derived{}, err
+	}
+	fftSize := c.fftSize()
+	d := Derived{
+		FFTSize:        fftSize,
+		FrameRate:      c.FS / float64(c.WinStep),
+		FreqResolution: c.FS / float64(fftSize),
+	}
+	d.Layout = c.layout()
+	for _, r := range d.Layout {
+		d.Names = append(d.Names, r.Names...)
+	}
+	d.Dim = len(d.Names)
+	return d, nil
+}
+
+// layout returns the layout of the "combined" feature vector. The configuration must be valid.
+func (c Config) layout() dsp.Layout {
+
+	var l dsp.Layout
+	start := 0
+	add := func(name string, names []string) {
+		l = append(l, dsp.Range{Name: name, Start: start, End: start + len(names), Names: names})
+		start += len(names)
+	}
+	for _, name := range c.Features {
+		names, _ := c.dimNames(name)
+		add(name, names)
+	}
+	static := l
+	for k := 1; k <= c.DeltaOrder; k++ {
+		for _, r := range static {
+			names := make([]string, len(r.Names))
+			for i, n := range r.Names {
+				names[i] = fmt.Sprintf("%s_d%d", n, k)
+			}
+			add(fmt.Sprintf("%s_d%d", r.Name, k), names)
+		}
+	}
+	return l
+}
+
+// dimNames returns the names of the elements of the output of a node.
+func (c Config) dimNames(node string) ([]string, error) {
+
+	indexed := func(n, first int, prefix string) []string {
+		names := make([]string, n)
+		for i := range names {
+			names[i] = fmt.Sprintf("%s[%s%d]", node, prefix, i+first)
+		}
+		return names
+	}
+	firstCep := 1
+	if c.FFTSize > 0 && c.KeepC0 {
+		firstCep = 0
+	}
+	switch node {
+	case "cepstral energy", "max cepstral energy", "normalized cepstral energy",
+		"delta energy", "delta delta energy", "log energy", "vad", "vad raw":
+		return []string{node}, nil
+	case "cepstrum", "unliftered cepstrum", "mean cepstrum", "zm cepstrum", "cmvn cepstrum",
+		"delta cepstrum", "delta delta cepstrum":
+		return indexed(c.CepSize, firstCep, "c"), nil
+	case "plp cepstrum":
+		return indexed(c.CepSize, 1, "c"), nil
+	case "filterbank", "log filterbank":
+		return indexed(c.FBSize, 0, ""), nil
+	case "spectrum", "power spectrum":
+		if c.FFTSize > 0 {
+			return indexed(c.FFTSize/2+1, 0, ""), nil
+		}
+		return indexed(1<<uint(c.LogFFTSize), 0, ""), nil
+	case "windowed", "frames", "zm frames":
+		return indexed(c.WinSize, 0, ""), nil
+	case "pitch":
+		return []string{node + "[f0]", node + "[voicing]"}, nil
+	}
+	return nil, fmt.Errorf("unknown dimension for feature [%s]", node)
+}
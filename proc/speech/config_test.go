@@ -0,0 +1,223 @@
+package speech
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/akualab/dsp/proc"
+	"github.com/akualab/dsp/proc/wav"
+)
+
+func TestSetDefaults(t *testing.T) {
+
+	c := Config{FS: 16000}
+	c.SetDefaults()
+	if c.WinSize != 400 || c.WinStep != 160 || c.LogFFTSize != 8 || c.FBMaxFreq != 8000 {
+		t.Fatalf("unexpected defaults, size:%d, step:%d, log fft size:%d, max freq:%f",
+			c.WinSize, c.WinStep, c.LogFFTSize, c.FBMaxFreq)
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Non-zero fields are not changed.
+	c = Config{FS: 8000, WinSize: 205, LogFFTSize: 9, CepSize: 8}
+	c.SetDefaults()
+	if c.WinSize != 205 || c.WinStep != 80 || c.LogFFTSize != 9 || c.CepSize != 8 {
+		t.Fatalf("unexpected defaults, size:%d, step:%d, log fft size:%d, cep size:%d",
+			c.WinSize, c.WinStep, c.LogFFTSize, c.CepSize)
+	}
+}
+
+func TestValidate(t *testing.T) {
+
+	valid := func() Config {
+		c := Config{
+			FS:         8000,
+			WinSize:    205,
+			WinStep:    80,
+			WinType:    proc.Hamming,
+			LogFFTSize: 8,
+			FBSize:     18,
+			FBMinFreq:  10,
+			FBMaxFreq:  3500,
+			CepSize:    8,
+		}
+		c.SetDefaults()
+		return c
+	}
+	if err := valid().Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, f := range []func(c *Config){
+		func(c *Config) { c.FS = 0 },
+		func(c *Config) { c.WinStep = -1 },
+		func(c *Config) { c.WinType = 99 },
+		func(c *Config) { c.FBMaxFreq = 5000 },
+		func(c *Config) { c.FBMinFreq = 3600 },
+		func(c *Config) { c.LogFFTSize = 6 },
+		func(c *Config) { c.FBSize = 250 },
+		func(c *Config) { c.CepSize = 18 },
+		func(c *Config) { c.PreEmphasis = 1 },
+		func(c *Config) { c.MaxF0 = 4000 },
+		func(c *Config) { c.Features = []string{"foo"} },
+		func(c *Config) { c.FFTSize = 128 },
+		func(c *Config) { c.FFTSize = 256; c.FBSize = 100 },
+		func(c *Config) { c.DeltaOrder = 2; c.DeltaWindow = 0 },
+	} {
+		c := valid()
+		f(&c)
+		if err := c.Validate(); err == nil {
+			t.Fatalf("case %d: expected error", i)
+		}
+	}
+}
+
+func TestDerived(t *testing.T) {
+
+	c := Config{
+		FS:         8000,
+		WinSize:    205,
+		WinStep:    80,
+		LogFFTSize: 8,
+		FBSize:     18,
+		FBMaxFreq:  3500,
+		CepSize:    3,
+		Features:   []string{"normalized cepstral energy", "zm cepstrum", "pitch"},
+	}
+	d, err := c.Derived()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.FFTSize != 512 || d.FrameRate != 100 || d.FreqResolution != 15.625 || d.Dim != 6 {
+		t.Fatalf("unexpected derived values: %s", d)
+	}
+	expected := []string{"normalized cepstral energy", "zm cepstrum[c1]", "zm cepstrum[c2]", "zm cepstrum[c3]", "pitch[f0]", "pitch[voicing]"}
+	for i, name := range expected {
+		if d.Names[i] != name {
+			t.Fatalf("expected name %s, got %s", name, d.Names[i])
+		}
+	}
+
+	c, err = Preset("htk-mfcc-8k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err = c.Derived()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Dim != 39 || d.Names[12] != "log energy" || d.Names[13] != "cepstrum[c1]_d1" || d.Names[38] != "log energy_d2" {
+		t.Fatalf("unexpected derived values: %s", d)
+	}
+
+	if _, err := (Config{}).Derived(); err == nil {
+		t.Fatal("expected error for empty config")
+	}
+}
+
+func TestLogMelFeatures(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "speech")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A tone followed by digital silence.
+	x := make([]float64, 8000)
+	for i := 0; i < 4000; i++ {
+		x[i] = 0.5 * math.Sin(2*math.Pi*300*float64(i)/8000)
+	}
+	path := writeJSON(t, dir, "logmel", wav.New("logmel", x, 8000))
+
+	c := Config{
+		FS:          8000,
+		WinType:     proc.Hamming,
+		FBSize:      20,
+		FeatureType: LogMelFeatures,
+		Energy:      true,
+		DeltaOrder:  2,
+	}
+	d, err := c.Derived()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.FFTSize != 256 || d.Dim != 63 || d.Names[0] != "log energy" || d.Names[1] != "log filterbank[0]" ||
+		d.Names[21] != "log energy_d1" {
+		t.Fatalf("unexpected derived values: %s", d)
+	}
+
+	feat := features(t, path, c)["logmel"]
+	if len(feat) != 99 {
+		t.Fatalf("expected 99 frames, got %d", len(feat))
+	}
+	floor := math.Log(DefaultLogFloor)
+	for i, v := range feat {
+		if len(v) != 63 {
+			t.Fatalf("expected dim 63, got %d", len(v))
+		}
+		for _, f := range v {
+			if math.IsNaN(f) || math.IsInf(f, 0) {
+				t.Fatalf("frame %d has value %f", i, f)
+			}
+		}
+	}
+	// Silent frames are floored.
+	for j := 0; j < 21; j++ {
+		compareFloats(t, floor, feat[90][j], "floor")
+		compareFloats(t, 0, feat[90][21+j], "delta")
+	}
+	if feat[10][0] < floor+10 {
+		t.Fatalf("expected tone energy, got %f", feat[10][0])
+	}
+
+	// The cepstral nodes are not built and the cepstrum size is not checked.
+	small := Config{FS: 8000, FBSize: 8, FeatureType: LogMelFeatures}
+	if feat := features(t, path, small)["logmel"]; len(feat) != 99 || len(feat[0]) != 8 {
+		t.Fatalf("unexpected features with 8 filters")
+	}
+	src, err := wav.NewSourceProc(path, wav.Fs(8000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	app, err := New("logmel", src, small)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"cepstrum", "cmvn cepstrum", "delta energy", "plp cepstrum", "pitch"} {
+		if _, err := app.NodesByName(name); err == nil {
+			t.Fatalf("unexpected node [%s] in log mel graph", name)
+		}
+	}
+
+	// Without a floor, the log of digital silence is -Inf. With an epsilon, it is log(epsilon).
+	noFloor := Config{FS: 8000, FBSize: 8, FeatureType: LogMelFeatures, LogFloor: -1}
+	if v := features(t, path, noFloor)["logmel"][90][0]; !math.IsInf(v, -1) {
+		t.Fatalf("expected -Inf without a floor, got %f", v)
+	}
+	noFloor.LogEpsilon = 1e-6
+	compareFloats(t, math.Log(1e-6), features(t, path, noFloor)["logmel"][90][0], "epsilon")
+
+	// With dither, silent frames have the energy of the noise.
+	c.Dither = 1e-3
+	c.DitherSeed = 5
+	feat = features(t, path, c)["logmel"]
+	egy := math.Log(200 * 1e-6)
+	if math.Abs(feat[90][0]-egy) > 0.5 {
+		t.Fatalf("expected log energy close to %f, got %f", egy, feat[90][0])
+	}
+	again := features(t, path, c)["logmel"]
+	if again[90][0] != feat[90][0] {
+		t.Fatalf("dither is not deterministic, got %f and %f", feat[90][0], again[90][0])
+	}
+}
+
+func compareFloats(t *testing.T, expected, actual float64, message string) {
+	if math.Abs(expected-actual) > 1e-9 {
+		t.Fatalf("[%s] expected %f, got %f", message, expected, actual)
+	}
+}
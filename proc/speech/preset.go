@@ -0,0 +1,152 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package speech
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/akualab/dsp/proc"
+)
+
+const (
+	// Smallest float32 such that 1+x != 1. Kaldi floors the mel energies and the frame energy with it.
+	float32Epsilon = DefaultLogFloor
+	// HTK replaces energies smaller than this value with a log energy of -1e10.
+	htkMinLogArg = 2.45e-308
+)
+
+// presets maps preset names to configurations. See Preset for details.
+var presets = map[string]Config{
+
+	// Kaldi compute-mfcc-feats with default options and --dither=0.
+	// Features are 13 dimensional: raw log energy followed by c1..c12.
+	"kaldi-mfcc-16k": Config{
+		FS:          16000,
+		BufSize:     defaultBufSize,
+		SampleScale: 32768,
+		Framing:     SnipEdges,
+		WinSize:     400,
+		WinStep:     160,
+		WinType:     proc.Povey | proc.Symmetric,
+		RemoveDC:    true,
+		PreEmphasis: 0.97,
+		FFTSize:     512,
+		FBSize:      23,
+		FBMinFreq:   20,
+		FBMaxFreq:   8000,
+		FBScale:     proc.MelHTK,
+		LogFloor:    float32Epsilon,
+		EnergyFloor: float32Epsilon,
+		CepSize:     12,
+		CepLifter:   22,
+		Features:    []string{"log energy", "cepstrum"},
+	},
+
+	// Kaldi compute-fbank-feats with default options and --dither=0.
+	// Features are 23 log mel filterbank values.
+	"kaldi-fbank-16k": Config{
+		FS:          16000,
+		BufSize:     defaultBufSize,
+		SampleScale: 32768,
+		Framing:     SnipEdges,
+		WinSize:     400,
+		WinStep:     160,
+		WinType:     proc.Povey | proc.Symmetric,
+		RemoveDC:    true,
+		PreEmphasis: 0.97,
+		FFTSize:     512,
+		FBSize:      23,
+		FBMinFreq:   20,
+		FBMaxFreq:   8000,
+		FBScale:     proc.MelHTK,
+		LogFloor:    float32Epsilon,
+		EnergyFloor: float32Epsilon,
+		CepSize:     12,
+		FeatureType: LogMelFeatures,
+		Features:    []string{"log filterbank"},
+	},
+
+	// HTK HCopy with TARGETKIND=MFCC_E_D_A, 25ms windows every 10ms, USEHAMMING=T,
+	// PREEMCOEF=0.97, NUMCHANS=26, NUMCEPS=12, CEPLIFTER=22 and ENORMALISE=F.
+	// Features are 39 dimensional: c1..c12 and the raw log energy, followed by their
+	// deltas and accelerations.
+	"htk-mfcc-8k": Config{
+		FS:          8000,
+		BufSize:     defaultBufSize,
+		SampleScale: 32768,
+		Framing:     SnipEdges,
+		WinSize:     200,
+		WinStep:     80,
+		WinType:     proc.Hamming | proc.Symmetric,
+		PreEmphasis: 0.97,
+		FFTSize:     256,
+		Magnitude:   true,
+		FBSize:      26,
+		FBMinFreq:   0,
+		FBMaxFreq:   4000,
+		FBScale:     proc.MelHTK,
+		LogFloor:    1,
+		EnergyFloor: htkMinLogArg,
+		CepSize:     12,
+		CepLifter:   22,
+		DeltaOrder:  2,
+		DeltaWindow: 2,
+		Features:    []string{"cepstrum", "log energy"},
+	},
+
+	// librosa.feature.melspectrogram with default options for a 22050 Hz waveform and
+	// pad_mode="reflect": n_fft=2048, hop_length=512, periodic Hann window, power spectrum,
+	// 128 Slaney mel filters with Slaney normalization. Features are the mel power values.
+	"librosa-melspec": Config{
+		FS:        22050,
+		BufSize:   defaultBufSize,
+		Framing:   PaddedFrames,
+		WinSize:   2048,
+		WinStep:   512,
+		WinType:   proc.Hanning,
+		FFTSize:   2048,
+		FBSize:    128,
+		FBMinFreq: 0,
+		FBMaxFreq: 11025,
+		FBScale:   proc.MelSlaney,
+		FBFlags:   proc.AreaNormalize | proc.HzTriangles,
+		CepSize:   20,
+		Features:  []string{"filterbank"},
+	},
+}
+
+// Presets returns the names of the available presets in alphabetical order.
+func Presets() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+/*
+Preset returns a configuration that follows the algorithm and default options of a standard toolkit.
+The waveform must have the sampling rate of the preset. Available presets:
+
+	kaldi-mfcc-16k   Kaldi compute-mfcc-feats defaults with --dither=0 (13 dims).
+	kaldi-fbank-16k  Kaldi compute-fbank-feats defaults with --dither=0 (23 dims).
+	htk-mfcc-8k      HTK MFCC_E_D_A with common HCopy settings (39 dims).
+	librosa-melspec  librosa.feature.melspectrogram defaults at 22050 Hz (128 dims).
+
+The returned configuration can be modified before calling New, for example, to select other features.
+The features are compared with reference dumps generated using each toolkit when the dumps are available
+in testdata. The presets without dumps have not been verified against the toolkit.
+*/
+func Preset(name string) (Config, error) {
+	c, ok := presets[name]
+	if !ok {
+		return Config{}, fmt.Errorf("unknown speech preset [%s], available presets: %v", name, Presets())
+	}
+	c.Features = append([]string{}, c.Features...)
+	return c, nil
+}
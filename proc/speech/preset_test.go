@@ -0,0 +1,269 @@
+package speech
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/akualab/dsp"
+	"github.com/akualab/dsp/proc/wav"
+	narray "github.com/akualab/narray/na64"
+)
+
+// tolerances for comparing features with the reference dumps in testdata.
+var presetTolerance = map[string]float64{
+	"kaldi-mfcc-16k":  1e-3,
+	"kaldi-fbank-16k": 1e-3,
+	"htk-mfcc-8k":     1e-3,
+	"librosa-melspec": 1e-4,
+}
+
+// features computes the features for each waveform in path.
+func features(t *testing.T, path string, c Config) map[string][][]float64 {
+	src, err := wav.NewSourceProc(path, wav.Fs(c.FS))
+	if err != nil {
+		t.Fatal(err)
+	}
+	app, err := New("preset", src, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := app.NodeByName("combined")
+	res := map[string][][]float64{}
+	for {
+		err := src.Next()
+		if err == wav.Done {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		app.Reset()
+		app.SetContext(src.NewContext())
+		var feat [][]float64
+		for i := 0; ; i++ {
+			v, err := out.Get(i)
+			if err == dsp.ErrOOB {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			feat = append(feat, append([]float64{}, v.(*narray.NArray).Data...))
+		}
+		res[src.ID()] = feat
+	}
+	return res
+}
+
+// writeJSON writes a waveform in JSON format and returns the path.
+func writeJSON(t *testing.T, dir, name string, w *wav.Waveform) string {
+	path := filepath.Join(dir, name+".json")
+	b, err := json.Marshal(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPresets(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "speech")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, c := range []struct {
+		name      string
+		dim       int
+		numFrames func(n int) int
+	}{
+		{"kaldi-mfcc-16k", 13, func(n int) int { return 1 + (n-400)/160 }},
+		{"kaldi-fbank-16k", 23, func(n int) int { return 1 + (n-400)/160 }},
+		{"htk-mfcc-8k", 39, func(n int) int { return 1 + (n-200)/80 }},
+		{"librosa-melspec", 128, func(n int) int { return 1 + n/512 }},
+	} {
+		config, err := Preset(c.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// One second of a noisy tone.
+		r := rand.New(rand.NewSource(33))
+		x := make([]float64, int(config.FS))
+		for i := range x {
+			x[i] = 0.3*math.Sin(2*math.Pi*440*float64(i)/config.FS) + 0.01*r.NormFloat64()
+		}
+		path := writeJSON(t, dir, c.name, wav.New("tone", x, config.FS))
+
+		d, err := config.Derived()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.Dim != c.dim {
+			t.Fatalf("%s: expected derived dim %d, got %d", c.name, c.dim, d.Dim)
+		}
+
+		src, err := wav.NewSourceProc(path, wav.Fs(config.FS))
+		if err != nil {
+			t.Fatal(err)
+		}
+		app, err := New("preset", src, config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		layout, err := app.Layout("combined")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(layout, d.Layout) || layout.Dim() != c.dim {
+			t.Fatalf("%s: unexpected layout %v", c.name, layout)
+		}
+
+		feat := features(t, path, config)["tone"]
+		if len(feat) != c.numFrames(len(x)) {
+			t.Fatalf("%s: expected %d frames, got %d", c.name, c.numFrames(len(x)), len(feat))
+		}
+		for i, v := range feat {
+			if len(v) != c.dim {
+				t.Fatalf("%s: expected dim %d, got %d", c.name, c.dim, len(v))
+			}
+			for _, f := range v {
+				if math.IsNaN(f) || math.IsInf(f, 0) {
+					t.Fatalf("%s: frame %d has value %f", c.name, i, f)
+				}
+			}
+		}
+
+		// Pitch and PLP frames are aligned with the preset frames.
+		extra := config
+		extra.Features = append(config.Features, "pitch", "plp cepstrum")
+		all := features(t, path, extra)["tone"]
+		if len(all) != len(feat) {
+			t.Fatalf("%s: expected %d frames with pitch and PLP, got %d", c.name, len(feat), len(all))
+		}
+		for i, v := range all {
+			for _, f := range v {
+				if math.IsNaN(f) || math.IsInf(f, 0) {
+					t.Fatalf("%s: frame %d has value %f", c.name, i, f)
+				}
+			}
+		}
+
+		// Kaldi replaces c0 with the log energy of the frame after removing the DC offset.
+		if c.name == "kaldi-mfcc-16k" {
+			frame := x[1600:2000]
+			var mean, egy float64
+			for _, v := range frame {
+				mean += v / 400
+			}
+			for _, v := range frame {
+				egy += (v - mean) * (v - mean) * 32768 * 32768
+			}
+			if math.Abs(math.Log(egy)-feat[10][0]) > 1e-9 {
+				t.Fatalf("expected log energy %f, got %f", math.Log(egy), feat[10][0])
+			}
+		}
+	}
+
+	if _, err := Preset("foo"); err == nil {
+		t.Fatal("expected error for unknown preset")
+	}
+}
+
+// readDump reads features in text format, one frame per line. Kaldi text archives
+// are supported: brackets and utterance IDs are ignored.
+func readDump(path string) ([][]float64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var feat [][]float64
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var frame []float64
+		for i, s := range strings.Fields(scanner.Text()) {
+			if s == "[" || s == "]" {
+				continue
+			}
+			v, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				if i == 0 {
+					continue
+				}
+				return nil, fmt.Errorf("%s: %s", path, err)
+			}
+			frame = append(frame, v)
+		}
+		if len(frame) > 0 {
+			feat = append(feat, frame)
+		}
+	}
+	return feat, scanner.Err()
+}
+
+// TestPresetGolden compares the features with reference dumps generated using each toolkit.
+// For each preset, testdata/<preset>/<id>.wav is processed and compared with testdata/<preset>/<id>.txt.
+// Every preset must have a reference waveform. Presets whose dumps have not been generated
+// are reported as skipped, they are not verified. (See testdata/README.md.)
+func TestPresetGolden(t *testing.T) {
+
+	for _, name := range Presets() {
+		t.Run(name, func(t *testing.T) {
+			testPresetGolden(t, name)
+		})
+	}
+}
+
+func testPresetGolden(t *testing.T, name string) {
+
+	wavs, _ := filepath.Glob(filepath.Join("testdata", name, "*.wav"))
+	if len(wavs) == 0 {
+		t.Fatalf("no reference waveforms found in testdata")
+	}
+	config, err := Preset(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tol := presetTolerance[name]
+	for _, path := range wavs {
+		dump := strings.TrimSuffix(path, ".wav") + ".txt"
+		ref, err := readDump(dump)
+		if os.IsNotExist(err) {
+			t.Skipf("reference dump %s is missing, the preset is not verified, generate it using the commands in testdata/README.md", dump)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		id := strings.TrimSuffix(filepath.Base(path), ".wav")
+		feat := features(t, path, config)[id]
+		if len(feat) != len(ref) {
+			t.Fatalf("%s: expected %d frames, got %d", path, len(ref), len(feat))
+		}
+		for i := range ref {
+			if len(feat[i]) != len(ref[i]) {
+				t.Fatalf("%s: frame %d - expected dim %d, got %d", path, i, len(ref[i]), len(feat[i]))
+			}
+			for j, v := range ref[i] {
+				if math.Abs(feat[i][j]-v) > tol*(1+math.Abs(v)) {
+					t.Fatalf("%s: frame %d, dim %d - expected %f, got %f", path, i, j, v, feat[i][j])
+				}
+			}
+		}
+	}
+}
@@ -12,20 +12,30 @@ package speech
 import (
 	"github.com/akualab/dsp"
 	"github.com/akualab/dsp/proc"
+	"github.com/akualab/dsp/proc/augment"
+	"github.com/akualab/dsp/proc/filter"
+	"github.com/akualab/dsp/proc/vad"
 	"github.com/akualab/dsp/proc/wav"
 )
 
+const defaultBufSize = 1000
+
+// DefaultLogFloor is the default floor for the filterbank and energy values before taking the log.
+const DefaultLogFloor = 1.1920929e-07
+
 // Config parameters for speech feature extractor.
 type Config struct {
 	// Sampling rate.
 	FS float64
+	// Pre-emphasis coefficient. Use zero to disable pre-emphasis.
+	PreEmphasis float64
 	// Processsor buffer size.
 	BufSize int
 	// Frame size in samples.
 	WinSize int
 	// Frame advance step in samples.
 	WinStep int
-	// Window Type (0: Rect, 1: Hann, 2: Hamm, 3: Blackman)
+	// Window Type (0: Rect, 1: Hann, 2: Hamm, 3: Blackman, see proc.WindowByName for other windows)
 	WinType int
 	// Log of the FFT size in samples.
 	LogFFTSize int
@@ -37,12 +47,109 @@ type Config struct {
 	FBMaxFreq float64
 	// Number of cepstral elements.
 	CepSize int
-	// Coefficients for computing deltas.
+	// Order of the all-pole model for PLP features. (Default is 12.)
+	PLPOrder int
+	// Coefficients for computing deltas. (Default is DefaultDeltaCoeff.)
 	DeltaCoeff []float64
-	// Name of the feature(s).
+	// Min F0 in Hertz for the pitch tracker. (Default is proc.DefaultMinF0.)
+	MinF0 float64
+	// Max F0 in Hertz for the pitch tracker. (Default is proc.DefaultMaxF0.)
+	MaxF0 float64
+	// Energy VAD threshold. Frames whose normalized cepstral energy is lower than the threshold
+	// are non-speech. Use zero to disable the VAD. (See package vad.)
+	VADThreshold float64
+	// Number of frames added before and after speech segments.
+	VADHangover int
+	// Min number of frames in a speech segment.
+	VADMinSpeech int
+	// Name of the feature(s). Any node name can be used, for example, "pitch" adds
+	// the F0 and the voicing probability and "plp cepstrum" adds PLP features.
+	// (Default depends on FeatureType.)
 	Features []string
+	// Type of features used when Features is empty. (Default is CepstrumFeatures.)
+	FeatureType FeatureType
+	// Prepend the "log energy" feature to the log mel filterbank features. (Only when
+	// FeatureType is LogMelFeatures and Features is empty.)
+	Energy bool
+
+	// The following parameters select the STFT front-end used by the presets. (See Preset.)
+
+	// Scale factor applied to the waveform. For example, use 32768 to process 16-bit samples
+	// in the integer range as HTK and Kaldi do. Zero leaves the waveform unchanged.
+	SampleScale float64
+	// Positions of the frames in the waveform. (Default is CenteredFrames.)
+	Framing Framing
+	// FFT size in samples. When non-zero, the spectrum is computed using proc.STFT, the filterbank
+	// is generated using FBScale and FBFlags, and the cepstrum is computed using an orthonormal DCT-II.
+	// LogFFTSize is ignored. Pre-emphasis is applied to each frame after removing the DC offset.
+	FFTSize int
+	// Remove the DC offset of each frame. (Only when FFTSize is set.)
+	RemoveDC bool
+	// Use the magnitude instead of the power spectrum. (Only when FFTSize is set.)
+	Magnitude bool
+	// Frequency scale of the filterbank. (Only when FFTSize is set, default is proc.MelHTK.)
+	FBScale proc.FreqScale
+	// Filterbank options. (Only when FFTSize is set.)
+	FBFlags proc.FilterbankFlag
+	// Filterbank values less than LogFloor are replaced with LogFloor before taking the log.
+	// Use a negative value to disable the floor. (Default is DefaultLogFloor. See proc.RobustLog.)
+	// Note that the default changes the "log filterbank" values of digital silence, which were
+	// -Inf in previous versions, and the cepstral features computed from them.
+	LogFloor float64
+	// Value added to the filterbank values before taking the log.
+	LogEpsilon float64
+	// Floor for the frame energy in the "log energy" node. Use a negative value to disable
+	// the floor. (Default is DefaultLogFloor.)
+	EnergyFloor float64
+	// Standard deviation of the Gaussian noise added to the waveform after scaling it
+	// by SampleScale. Use zero to disable dithering. (See augment.DitherProc.)
+	Dither float64
+	// Seed for the dither noise. The generator is initialized on Reset so the dither
+	// for a waveform does not depend on its position in the stream.
+	DitherSeed int64
+	// Keep c0 as the first cepstral coefficient. The cepstrum has CepSize coefficients.
+	// (Only when FFTSize is set.)
+	KeepC0 bool
+	// Sinusoidal cepstral lifter parameter. Use zero to disable liftering. (Only when FFTSize is set.)
+	CepLifter float64
+	// When DeltaOrder is non-zero, regression deltas of orders 1 to DeltaOrder computed over
+	// +/-DeltaWindow frames are appended to the features. (See proc.DeltaProc.)
+	DeltaOrder int
+	// Number of frames on each side used to compute regression deltas.
+	DeltaWindow int
 }
 
+// Framing determines the position of the frames in the waveform.
+type Framing int
+
+const (
+	// CenteredFrames centers frame i on sample i*WinStep+WinStep/2. Samples before the start
+	// of the waveform are reflected.
+	CenteredFrames Framing = iota
+	// SnipEdges starts frame i on sample i*WinStep and only uses frames that are fully
+	// inside the waveform. (As in HTK and Kaldi.)
+	SnipEdges
+	// PaddedFrames centers frame i on sample i*WinStep. The waveform is padded by reflection
+	// with WinSize/2 samples on both sides. (As in librosa with center=True.)
+	PaddedFrames
+)
+
+// FeatureType selects the default features.
+type FeatureType int
+
+const (
+	// CepstrumFeatures are energy and cepstral features with deltas. (See DefaultFeatures.)
+	CepstrumFeatures FeatureType = iota
+	// LogMelFeatures are log mel filterbank features, optionally preceded by the log energy
+	// of the frame. (See DefaultLogMelFeatures and Config.Energy.) Use DeltaOrder to append
+	// regression deltas. The filterbank is computed from the STFT. (See Config.FFTSize.)
+	// The cepstrum, energy, PLP and pitch nodes are only built when they are selected in Features.
+	LogMelFeatures
+)
+
+// DefaultLogMelFeatures has a list of the default feature names for LogMelFeatures.
+var DefaultLogMelFeatures = []string{"log filterbank"}
+
 // DefaultFeatures has a list of the default feature names.
 var DefaultFeatures = []string{
 	"normalized cepstral energy",
@@ -53,85 +160,289 @@ var DefaultFeatures = []string{
 	"delta delta cepstrum",
 }
 
-// New creates a new speech dsp app.
+// New creates a new speech dsp app. Zero fields in the configuration are set to their
+// default values and the configuration is validated. (See Config.SetDefaults and Config.Validate.)
+// The layout of the "combined" node names the features in the output vector. (See Config.Derived
+// and dsp.App.Layout.)
 func New(name string, source *wav.SourceProc, c Config) (*dsp.App, error) {
 
-	if len(c.Features) == 0 {
-		c.Features = DefaultFeatures
+	c.SetDefaults()
+	if err := c.Validate(); err != nil {
+		return nil, err
 	}
 	app := dsp.NewApp(name)
-	indices, coeff := proc.GenerateFilterbank(1<<uint(c.LogFFTSize), c.FBSize, c.FS, c.FBMinFreq, c.FBMaxFreq)
+	var logFB dsp.Node
+	specSize := 1 << uint(c.LogFFTSize)
+	if c.FFTSize > 0 {
+		var err error
+		logFB, err = addSTFT(app, source, c)
+		if err != nil {
+			return nil, err
+		}
+		specSize = c.FFTSize / 2
+	} else {
+		logFB = addDFT(app, source, c)
+	}
 
-	cep := app.Chain(
-		app.Add("cepstrum", proc.DCT(c.FBSize, c.CepSize)),
-		app.Add("log filterbank", proc.Log()),
-		app.Add("filterbank", proc.Filterbank(indices, coeff)),
-		app.Add("spectrum", proc.SpectralEnergy(c.LogFFTSize)),
-		app.Add("windowed", proc.NewWindowProc(c.WinStep, c.WinSize, c.WinType, true)),
-		app.Add("wav", source),
-	)
+	// Cepstral features.
+	if c.builds(cepstrumNodes...) {
+		cep := addCepstrum(app, logFB, c)
 
-	meanCep := app.Connect(
-		app.Add("mean cepstrum", proc.Mean()),
-		cep,
-	)
+		meanCep := app.Connect(
+			app.Add("mean cepstrum", proc.Mean()),
+			cep,
+		)
 
-	zmCep := app.Connect(
-		app.Add("zm cepstrum", proc.Sub()),
-		cep,
-		meanCep,
-	)
+		zmCep := app.Connect(
+			app.Add("zm cepstrum", proc.Sub()),
+			cep,
+			meanCep,
+		)
 
-	// Energy features.
-	egy := app.Connect(
-		app.Add("cepstral energy", proc.Sum()),
-		app.NodeByName("log filterbank"),
-	)
+		// Mean and variance normalized cepstrum.
+		app.Connect(
+			app.Add("cmvn cepstrum", proc.NewCMVNProc(proc.UtteranceCMVN, true)),
+			cep,
+		)
 
-	maxEgy := app.Connect(
-		app.Add("max cepstral energy", proc.MaxWin()),
-		egy,
-	)
+		// Delta cepstrum features.
+		dCep := app.Connect(
+			app.Add("delta cepstrum", proc.NewDiffProc(c.CepSize, c.BufSize, c.DeltaCoeff)),
+			zmCep,
+		)
+		app.Connect(
+			app.Add("delta delta cepstrum", proc.NewDiffProc(c.CepSize, c.BufSize, c.DeltaCoeff)),
+			dCep,
+		)
+	}
 
-	// Subtract max energy from energy.
-	normEgy := app.Connect(
-		app.Add("normalized cepstral energy", proc.Sub()),
-		egy,
-		maxEgy,
-	)
+	// Energy features. The VAD uses the normalized energy.
+	var normEgy dsp.Node
+	if c.builds(energyNodes...) || c.VADThreshold != 0 {
+		egy := app.Connect(
+			app.Add("cepstral energy", proc.Sum()),
+			logFB,
+		)
 
-	// Delta cepstrum features.
-	dCep := app.Connect(
-		app.Add("delta cepstrum", proc.NewDiffProc(c.CepSize, c.BufSize, c.DeltaCoeff)),
-		zmCep,
-	)
-	app.Connect(
-		app.Add("delta delta cepstrum", proc.NewDiffProc(c.CepSize, c.BufSize, c.DeltaCoeff)),
-		dCep,
-	)
+		maxEgy := app.Connect(
+			app.Add("max cepstral energy", proc.MaxWin()),
+			egy,
+		)
 
-	// Delta energy features.
-	dEgy := app.Connect(
-		app.Add("delta energy", proc.NewDiffProc(1, c.BufSize, c.DeltaCoeff)),
-		normEgy,
-	)
-	app.Connect(
-		app.Add("delta delta energy", proc.NewDiffProc(1, c.BufSize, c.DeltaCoeff)),
-		dEgy,
-	)
+		// Subtract max energy from energy.
+		normEgy = app.Connect(
+			app.Add("normalized cepstral energy", proc.Sub()),
+			egy,
+			maxEgy,
+		)
+
+		// Delta energy features.
+		dEgy := app.Connect(
+			app.Add("delta energy", proc.NewDiffProc(1, c.BufSize, c.DeltaCoeff)),
+			normEgy,
+		)
+		app.Connect(
+			app.Add("delta delta energy", proc.NewDiffProc(1, c.BufSize, c.DeltaCoeff)),
+			dEgy,
+		)
+	}
+
+	// PLP cepstrum computed from the same power spectrum using FBSize Bark bands.
+	if c.builds("plp cepstrum", "power spectrum") {
+		power := app.NodeByName("spectrum")
+		if c.FFTSize > 0 && c.Magnitude {
+			power = app.Connect(
+				app.Add("power spectrum", proc.Power()),
+				app.NodeByName("stft"),
+			)
+		}
+		app.Connect(
+			app.Add("plp cepstrum", proc.PLP(c.FS, specSize, c.FBSize, c.PLPOrder, c.CepSize)),
+			power,
+		)
+	}
+
+	// Pitch features: F0 and voicing probability. The pitch frames are aligned with the feature frames.
+	if c.builds("pitch") {
+		pitchSrc := app.NodeByName("wav")
+		if c.Framing == PaddedFrames {
+			pitchSrc = app.NodeByName("padded")
+		}
+		app.Connect(
+			app.Add("pitch", proc.NewPitchProc(c.FS, c.MinF0, c.MaxF0, c.WinStep, c.WinSize, c.Framing == CenteredFrames)),
+			pitchSrc,
+		)
+	}
 
 	// Put three energy features and cepstrum features in a single vector.
 	nodes, err := app.NodesByName(c.Features...)
 	if err != nil {
 		return nil, err
 	}
-	app.Connect(
+
+	// Append regression deltas of the static features.
+	layout := c.layout()
+	if c.DeltaOrder > 0 {
+		static := app.Connect(
+			app.Add("static", proc.Join()),
+			nodes...,
+		)
+		if err := app.SetLayout("static", layout[:len(c.Features)]); err != nil {
+			return nil, err
+		}
+		deltas := app.Connect(
+			app.Add("regression deltas", proc.NewDeltaProc(c.DeltaWindow, c.DeltaOrder, proc.Replicate)),
+			static,
+		)
+		nodes = []dsp.Node{static, deltas}
+	}
+	combined := app.Connect(
 		app.Add("combined", proc.Join()),
 		nodes...,
 	)
+	if err := app.SetLayout("combined", layout); err != nil {
+		return nil, err
+	}
+
+	// Energy-based VAD. The "speech frames" node has the combined features for speech frames only.
+	if c.VADThreshold != 0 {
+		decisions := app.Chain(
+			app.Add("vad", vad.NewHangoverProc(c.VADHangover, c.VADMinSpeech)),
+			app.Add("vad raw", vad.Threshold(c.VADThreshold, true)),
+			normEgy,
+		)
+		app.Connect(
+			app.Add("speech frames", vad.NewDropProc()),
+			combined,
+			decisions,
+		)
+		if err := app.SetLayout("speech frames", layout); err != nil {
+			return nil, err
+		}
+	}
 	return app, nil
 }
 
+// addSource adds the "wav" node and the optional "dithered" and "scaled" nodes.
+// Returns the nodes in chain order.
+func addSource(app *dsp.App, source *wav.SourceProc, c Config) []dsp.Node {
+	chain := []dsp.Node{}
+	if c.Dither > 0 {
+		chain = append(chain, app.Add("dithered", augment.NewDitherProc(c.Dither, c.DitherSeed)))
+	}
+	if c.SampleScale != 0 {
+		chain = append(chain, app.Add("scaled", proc.Scale(c.SampleScale)))
+	}
+	return append(chain, app.Add("wav", source))
+}
+
+// addDFT adds the nodes from "wav" to "log filterbank" using SpectralEnergy and returns the log filterbank node.
+// The "log energy" node has the log energy of the frames before windowing.
+func addDFT(app *dsp.App, source *wav.SourceProc, c Config) dsp.Node {
+
+	chain := []dsp.Node{}
+	if c.Framing == PaddedFrames {
+		chain = append(chain, app.Add("padded", proc.Pad(c.WinSize/2, c.WinSize/2, proc.Reflect)))
+	}
+	if c.PreEmphasis > 0 {
+		chain = append(chain, app.Add("pre-emphasis", filter.PreEmphasis(c.PreEmphasis)))
+	}
+	chain = append(chain, addSource(app, source, c)...)
+	src := app.Chain(chain...)
+
+	app.Chain(
+		app.Add("log energy", proc.LogEnergy(c.EnergyFloor)),
+		app.Add("frames", proc.NewWindowProc(c.WinStep, c.WinSize, proc.Rectangular, c.Framing == CenteredFrames)),
+		src,
+	)
+
+	indices, coeff := proc.GenerateFilterbank(1<<uint(c.LogFFTSize), c.FBSize, c.FS, c.FBMinFreq, c.FBMaxFreq)
+	return app.Chain(
+		app.Add("log filterbank", proc.RobustLog(c.LogFloor, c.LogEpsilon)),
+		app.Add("filterbank", proc.Filterbank(indices, coeff)),
+		app.Add("spectrum", proc.SpectralEnergy(c.LogFFTSize)),
+		app.Add("windowed", proc.NewWindowProc(c.WinStep, c.WinSize, c.WinType, c.Framing == CenteredFrames)),
+		src,
+	)
+}
+
+// addSTFT adds the nodes from "wav" to "log filterbank" using STFT and returns the log filterbank node.
+// The "log energy" node has the log energy of the frames before pre-emphasis and windowing.
+func addSTFT(app *dsp.App, source *wav.SourceProc, c Config) (dsp.Node, error) {
+
+	indices, coeff, err := proc.GenerateScaleFilterbank(c.FFTSize/2, c.FBSize, c.FS, c.FBMinFreq, c.FBMaxFreq, c.FBScale, c.FBFlags)
+	if err != nil {
+		return dsp.Node{}, err
+	}
+
+	// Waveform to frames.
+	chain := []dsp.Node{}
+	if c.RemoveDC {
+		chain = append(chain, app.Add("zm frames", proc.RemoveDC()))
+	}
+	chain = append(chain, app.Add("frames", proc.NewWindowProc(c.WinStep, c.WinSize, proc.Rectangular, c.Framing == CenteredFrames)))
+	if c.Framing == PaddedFrames {
+		chain = append(chain, app.Add("padded", proc.Pad(c.WinSize/2, c.WinSize/2, proc.Reflect)))
+	}
+	chain = append(chain, addSource(app, source, c)...)
+	frames := app.Chain(chain...)
+
+	app.Connect(
+		app.Add("log energy", proc.LogEnergy(c.EnergyFloor)),
+		frames,
+	)
+
+	// Frames to log filterbank.
+	spectrum := proc.Power()
+	if c.Magnitude {
+		spectrum = proc.Magnitude()
+	}
+	chain = []dsp.Node{
+		app.Add("log filterbank", proc.RobustLog(c.LogFloor, c.LogEpsilon)),
+		app.Add("filterbank", proc.Filterbank(indices, coeff)),
+		app.Add("spectrum", spectrum),
+		app.Add("stft", proc.STFT(c.FFTSize)),
+		app.Add("windowed", proc.ApplyWindow(c.WinType)),
+	}
+	if c.PreEmphasis > 0 {
+		chain = append(chain, app.Add("pre-emphasis", proc.FramePreEmphasis(c.PreEmphasis)))
+	}
+	chain = append(chain, frames)
+	return app.Chain(chain...), nil
+}
+
+// addCepstrum adds the "cepstrum" node computed from the log filterbank and returns it.
+// With FFTSize set, the cepstrum is computed using an orthonormal DCT-II and the optional lifter.
+func addCepstrum(app *dsp.App, logFB dsp.Node, c Config) dsp.Node {
+	if c.FFTSize == 0 {
+		return app.Connect(
+			app.Add("cepstrum", proc.DCT(c.FBSize, c.CepSize)),
+			logFB,
+		)
+	}
+	first := 1
+	if c.KeepC0 {
+		first = 0
+	}
+	chain := []dsp.Node{}
+	if c.CepLifter != 0 {
+		chain = append(chain, app.Add("cepstrum", proc.Lifter(c.CepLifter, first)))
+	}
+	chain = append(chain,
+		app.Add(dctName(c.CepLifter), proc.TypedDCT(proc.DCT2, c.FBSize, c.CepSize, true, c.KeepC0)),
+		logFB,
+	)
+	return app.Chain(chain...)
+}
+
+// dctName returns the name of the DCT node, which is the cepstrum unless it is liftered.
+func dctName(lifter float64) string {
+	if lifter != 0 {
+		return "unliftered cepstrum"
+	}
+	return "cepstrum"
+}
+
 var (
 	// MelFilterbankIndices are the indices of the filters in the filterbank.
 	MelFilterbankIndices = []int{10, 11, 14, 17, 20, 23, 27, 30, 33, 36, 40, 45, 50, 56, 62, 69, 76, 84}
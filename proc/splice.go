@@ -0,0 +1,87 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package proc
+
+import (
+	"fmt"
+
+	"github.com/akualab/dsp"
+	narray "github.com/akualab/narray/na64"
+)
+
+/*
+SpliceProc concatenates context frames. The output for index idx is the concatenation of input frames
+
+	x[t-left], ..., x[t], ..., x[t+right]  where t = idx * stride
+
+The size of the output vector is (left+right+1) times the size of the input vector. Use stride
+one to keep all the frames. With stride s, only every s-th frame is kept and the output has
+ceil(N/s) frames where N is the number of input frames. Frames outside of the stream are determined
+using the boundary policy.
+*/
+type SpliceProc struct {
+	left, right int
+	stride      int
+	boundary    *boundary
+	*dsp.Proc
+}
+
+// NewSpliceProc returns a new splice processor.
+func NewSpliceProc(left, right, stride int, policy BoundaryPolicy) *SpliceProc {
+	sp := &SpliceProc{
+		left:   left,
+		right:  right,
+		stride: stride,
+		Proc:   dsp.NewProc(defaultBufSize, nil),
+	}
+	sp.boundary = &boundary{policy: policy, in: sp.Proc}
+	sp.Reset()
+	return sp
+}
+
+// Get implements the dsp.Framer interface.
+func (sp *SpliceProc) Get(idx int) (dsp.Value, error) {
+	if idx < 0 {
+		return nil, dsp.ErrOOB
+	}
+	val, ok := sp.GetCache(idx)
+	if ok {
+		return val, nil
+	}
+	if sp.left < 0 || sp.right < 0 || sp.stride < 1 {
+		return nil, fmt.Errorf("bad splice parameters, left:%d, right:%d, stride:%d", sp.left, sp.right, sp.stride)
+	}
+	in := dsp.Processers(sp.Inputs())
+	t := idx * sp.stride
+	center, err := in.Get(t)
+	if err != nil {
+		return nil, err
+	}
+	dim := len(center.(*narray.NArray).Data)
+	res := narray.New(dim * (sp.left + sp.right + 1))
+	for j := -sp.left; j <= sp.right; j++ {
+		i, ok, err := sp.boundary.index(t + j)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		vec, err := in.Get(i)
+		if err != nil {
+			return nil, err
+		}
+		copy(res.Data[(j+sp.left)*dim:], vec.(*narray.NArray).Data)
+	}
+	sp.SetCache(idx, res)
+	return res, nil
+}
+
+// Reset implements the dsp.Resetter interface.
+func (sp *SpliceProc) Reset() {
+	sp.boundary.reset()
+	sp.Proc.Reset()
+}
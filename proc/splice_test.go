@@ -0,0 +1,55 @@
+package proc
+
+import (
+	"testing"
+
+	"github.com/akualab/dsp"
+)
+
+func TestSpliceProc(t *testing.T) {
+
+	data := [][]float64{{0, 10}, {1, 11}, {2, 12}, {3, 13}, {4, 14}}
+	for _, c := range []struct {
+		left, right, stride int
+		policy              BoundaryPolicy
+		expected            [][]float64
+	}{
+		{1, 1, 1, Replicate, [][]float64{
+			{0, 10, 0, 10, 1, 11},
+			{0, 10, 1, 11, 2, 12},
+			{1, 11, 2, 12, 3, 13},
+			{2, 12, 3, 13, 4, 14},
+			{3, 13, 4, 14, 4, 14},
+		}},
+		{2, 0, 1, Reflect, [][]float64{
+			{2, 12, 1, 11, 0, 10},
+			{1, 11, 0, 10, 1, 11},
+			{0, 10, 1, 11, 2, 12},
+			{1, 11, 2, 12, 3, 13},
+			{2, 12, 3, 13, 4, 14},
+		}},
+		{0, 1, 2, Zero, [][]float64{
+			{0, 10, 1, 11},
+			{2, 12, 3, 13},
+			{4, 14, 0, 0},
+		}},
+		{0, 0, 1, Zero, data},
+	} {
+		app := dsp.NewApp("splice")
+		out := app.Chain(
+			app.Add("splice", NewSpliceProc(c.left, c.right, c.stride, c.policy)),
+			app.Add("features", frames(data)),
+		)
+		v := streamValues(t, out)
+		if len(v) != len(c.expected) {
+			t.Fatalf("expected %d frames, got %d", len(c.expected), len(v))
+		}
+		for i := range v {
+			compareSliceFloat(t, c.expected[i], v[i], "splice", 1e-15)
+		}
+	}
+
+	if _, err := NewSpliceProc(1, 1, 0, Zero).Get(0); err == nil {
+		t.Fatal("expected error for zero stride")
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vad
+
+import (
+	"github.com/akualab/dsp"
+	narray "github.com/akualab/narray/na64"
+)
+
+/*
+HangoverProc smooths a stream of VAD decisions. Speech runs shorter than minSpeech frames are
+changed to non-speech. Then, each speech run is extended by hangover frames at both ends so
+the onsets and the low energy tails of words are not clipped.
+
+The decisions are computed for the entire stream the first time a frame is requested.
+*/
+type HangoverProc struct {
+	hangover  int
+	minSpeech int
+	decisions []*narray.NArray
+	*dsp.Proc
+}
+
+// NewHangoverProc returns a processor to smooth VAD decisions.
+func NewHangoverProc(hangover, minSpeech int) *HangoverProc {
+	return &HangoverProc{
+		hangover:  hangover,
+		minSpeech: minSpeech,
+		Proc:      dsp.NewProc(defaultBufSize, nil),
+	}
+}
+
+// Get implements the dsp.Framer interface.
+func (hp *HangoverProc) Get(idx int) (dsp.Value, error) {
+	if idx < 0 {
+		return nil, dsp.ErrOOB
+	}
+	if hp.decisions == nil {
+		if err := hp.smooth(); err != nil {
+			return nil, err
+		}
+	}
+	if idx >= len(hp.decisions) {
+		return nil, dsp.ErrOOB
+	}
+	return hp.decisions[idx], nil
+}
+
+// Reset implements the dsp.Resetter interface.
+func (hp *HangoverProc) Reset() {
+	hp.decisions = nil
+	hp.Proc.Reset()
+}
+
+func (hp *HangoverProc) smooth() error {
+	in := []bool{}
+	for i := 0; ; i++ {
+		vec, err := dsp.Processers(hp.Inputs()).Get(i)
+		if err == dsp.ErrOOB {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		in = append(in, isSpeech(vec))
+	}
+	out := make([]bool, len(in), len(in))
+	for _, seg := range segments(in) {
+		if seg.End-seg.Start < hp.minSpeech {
+			continue
+		}
+		for i := seg.Start - hp.hangover; i < seg.End+hp.hangover; i++ {
+			if i >= 0 && i < len(out) {
+				out[i] = true
+			}
+		}
+	}
+	hp.decisions = make([]*narray.NArray, len(out), len(out))
+	for i, s := range out {
+		hp.decisions[i] = decision(s)
+	}
+	return nil
+}
+
+// Segment is a speech segment. Start and End are frame indices, End is exclusive.
+type Segment struct {
+	Start, End int
+}
+
+// Seconds returns the segment boundaries in seconds for frames with step size stepSize samples
+// and sampling rate fs.
+func (s Segment) Seconds(stepSize int, fs float64) (start, end float64) {
+	return float64(s.Start*stepSize) / fs, float64(s.End*stepSize) / fs
+}
+
+// segments returns the runs of speech frames.
+func segments(d []bool) []Segment {
+	segs := []Segment{}
+	start := -1
+	for i, s := range d {
+		switch {
+		case s && start < 0:
+			start = i
+		case !s && start >= 0:
+			segs = append(segs, Segment{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		segs = append(segs, Segment{start, len(d)})
+	}
+	return segs
+}
+
+// Segments returns the list of speech segments from a stream of decisions.
+func Segments(decisions dsp.Framer) ([]Segment, error) {
+	d := []bool{}
+	for i := 0; ; i++ {
+		vec, err := decisions.Get(i)
+		if err == dsp.ErrOOB {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		d = append(d, isSpeech(vec))
+	}
+	return segments(d), nil
+}
+
+// DropProc removes non-speech frames. Input 0 is the stream of frames and input 1 is the stream of
+// VAD decisions. The output for index idx is the idx-th speech frame. Returns ErrOOB after the last
+// speech frame. Use Index to get the input frame index of an output frame.
+type DropProc struct {
+	index []int
+	next  int
+	*dsp.Proc
+}
+
+// NewDropProc returns a processor that drops non-speech frames.
+func NewDropProc() *DropProc {
+	return &DropProc{
+		Proc: dsp.NewProc(defaultBufSize, nil),
+	}
+}
+
+// Get implements the dsp.Framer interface.
+func (dp *DropProc) Get(idx int) (dsp.Value, error) {
+	i, err := dp.Index(idx)
+	if err != nil {
+		return nil, err
+	}
+	return dsp.Processers(dp.Inputs()).Get(i)
+}
+
+// Index returns the index of the input frame for output frame idx.
+func (dp *DropProc) Index(idx int) (int, error) {
+	if idx < 0 {
+		return 0, dsp.ErrOOB
+	}
+	in, err := dsp.Processers(dp.Inputs()).CheckInputs(2)
+	if err != nil {
+		return 0, err
+	}
+	for len(dp.index) <= idx {
+		vec, err := in[1].Get(dp.next)
+		if err != nil {
+			return 0, err
+		}
+		if isSpeech(vec) {
+			dp.index = append(dp.index, dp.next)
+		}
+		dp.next++
+	}
+	return dp.index[idx], nil
+}
+
+// Reset implements the dsp.Resetter interface.
+func (dp *DropProc) Reset() {
+	dp.index = nil
+	dp.next = 0
+	dp.Proc.Reset()
+}
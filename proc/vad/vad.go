@@ -0,0 +1,229 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package vad provides processors for voice activity detection.
+
+Detection is done in three steps. First, a score is computed for each frame, for example, the
+"normalized cepstral energy" computed by the speech app, the spectral entropy (see Entropy), or the
+likelihood ratio of a statistical model (see StatProc). Second, the score is converted to a
+per-frame decision using Threshold and the decisions are smoothed using HangoverProc. Decisions are
+vectors of size one with value 1 for speech and 0 for non-speech. Finally, the decisions can be used
+to get a list of speech segments (see Segments) or to remove non-speech frames from a feature stream
+(see DropProc).
+
+For example, an energy-based VAD using the speech app:
+
+	vad := app.Chain(
+	  app.Add("vad", vad.NewHangoverProc(10, 5)),
+	  app.Add("vad raw", vad.Threshold(-5, true)),
+	  app.NodeByName("normalized cepstral energy"),
+	)
+	app.Connect(
+	  app.Add("speech frames", vad.NewDropProc()),
+	  app.NodeByName("combined"),
+	  vad,
+	)
+*/
+package vad
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/akualab/dsp"
+	narray "github.com/akualab/narray/na64"
+)
+
+const defaultBufSize = 1000
+
+// Speech and NonSpeech are the values of the VAD decisions.
+const (
+	NonSpeech = 0.0
+	Speech    = 1.0
+)
+
+// decision returns a decision vector.
+func decision(speech bool) *narray.NArray {
+	v := narray.New(1)
+	if speech {
+		v.Data[0] = Speech
+	}
+	return v
+}
+
+// isSpeech returns true if the decision in vector v is speech.
+func isSpeech(v dsp.Value) bool {
+	return v.(*narray.NArray).Data[0] > 0.5
+}
+
+// Threshold converts a score in the first element of the input vectors to a decision.
+// If above is true, a frame is speech when the score is greater than th, otherwise a frame is
+// speech when the score is lower than th.
+func Threshold(th float64, above bool) dsp.Processer {
+	return dsp.NewProc(defaultBufSize, func(idx int, in ...dsp.Processer) (dsp.Value, error) {
+		vec, err := dsp.Processers(in).Get(idx)
+		if err != nil {
+			return nil, err
+		}
+		score := vec.(*narray.NArray).Data[0]
+		return decision((score > th) == above), nil
+	})
+}
+
+/*
+Entropy returns the normalized spectral entropy of the input power spectrum.
+
+	p[k] = S[k] / sum_j S[j]
+
+	H = - sum_k p[k] log(p[k]) / log(K)
+
+where K is the size of the spectrum. The value is between zero and one. The spectrum of speech is
+more structured than the spectrum of background noise which results in lower entropy values. Use
+Threshold with above=false to get the decisions. Frames with zero energy have entropy one.
+*/
+func Entropy() dsp.Processer {
+	return dsp.NewProc(defaultBufSize, func(idx int, in ...dsp.Processer) (dsp.Value, error) {
+		vec, err := dsp.Processers(in).Get(idx)
+		if err != nil {
+			return nil, err
+		}
+		s := vec.(*narray.NArray).Data
+		v := narray.New(1)
+		v.Data[0] = 1
+		sum := 0.0
+		for _, x := range s {
+			sum += x
+		}
+		if sum <= 0 || len(s) < 2 {
+			return v, nil
+		}
+		var h float64
+		for _, x := range s {
+			if x > 0 {
+				p := x / sum
+				h -= p * math.Log(p)
+			}
+		}
+		v.Data[0] = h / math.Log(float64(len(s)))
+		return v, nil
+	})
+}
+
+/*
+StatProc is the statistical model VAD of Sohn, Kim and Sung. ("A statistical model-based voice
+activity detector", IEEE Signal Processing Letters, 1999.) The input is a power spectrum, for example,
+the output of proc.SpectralEnergy or proc.Power. The output is the mean log likelihood ratio of the
+speech and non-speech hypotheses over the spectral components:
+
+	score = 1/K sum_k gamma[k] xi[k] / (1 + xi[k]) - log(1 + xi[k])
+
+where gamma[k] is the a posteriori SNR and xi[k] is the a priori SNR estimated using the decision-directed
+method. The noise spectrum is initialized using the first NoiseFrames frames and is updated for frames
+whose score is lower than the threshold. Use Threshold with the same threshold to get the decisions.
+
+Frames are processed sequentially, the score for frame idx requires processing all previous frames.
+*/
+type StatProc struct {
+	// NoiseFrames is the number of frames used to initialize the noise spectrum.
+	NoiseFrames int
+	// Alpha is the weight of the previous frame in the decision-directed a priori SNR estimate.
+	Alpha float64
+	// NoiseRate is the weight of the previous noise spectrum in the noise update.
+	NoiseRate float64
+
+	threshold float64
+	noise     []float64
+	gain      []float64 // Wiener gain times a posteriori SNR of previous frame.
+	scores    []*narray.NArray
+	*dsp.Proc
+}
+
+// NewStatProc returns a statistical model VAD processor. The noise spectrum is updated when the score
+// is lower than threshold.
+func NewStatProc(threshold float64) *StatProc {
+	return &StatProc{
+		NoiseFrames: 10,
+		Alpha:       0.98,
+		NoiseRate:   0.95,
+		threshold:   threshold,
+		Proc:        dsp.NewProc(defaultBufSize, nil),
+	}
+}
+
+// Get implements the dsp.Framer interface.
+func (sp *StatProc) Get(idx int) (dsp.Value, error) {
+	if idx < 0 {
+		return nil, dsp.ErrOOB
+	}
+	if sp.noise == nil {
+		if err := sp.init(); err != nil {
+			return nil, err
+		}
+	}
+	for len(sp.scores) <= idx {
+		vec, err := dsp.Processers(sp.Inputs()).Get(len(sp.scores))
+		if err != nil {
+			return nil, err
+		}
+		s := vec.(*narray.NArray).Data
+		if len(s) != len(sp.noise) {
+			return nil, fmt.Errorf("mismatch in spectrum size, expected %d, got %d", len(sp.noise), len(s))
+		}
+		var score float64
+		for k, x := range s {
+			gamma := x / sp.noise[k]
+			xi := sp.Alpha*sp.gain[k] + (1-sp.Alpha)*math.Max(gamma-1, 0)
+			xi = math.Max(xi, 1e-3)
+			score += gamma*xi/(1+xi) - math.Log(1+xi)
+			g := xi / (1 + xi)
+			sp.gain[k] = g * g * gamma
+		}
+		score /= float64(len(s))
+		if score < sp.threshold {
+			for k, x := range s {
+				sp.noise[k] = math.Max(sp.NoiseRate*sp.noise[k]+(1-sp.NoiseRate)*x, 1e-10)
+			}
+		}
+		v := narray.New(1)
+		v.Data[0] = score
+		sp.scores = append(sp.scores, v)
+	}
+	return sp.scores[idx], nil
+}
+
+// init estimates the noise spectrum.
+func (sp *StatProc) init() error {
+	var n int
+	for ; n < sp.NoiseFrames || n == 0; n++ {
+		vec, err := dsp.Processers(sp.Inputs()).Get(n)
+		if err == dsp.ErrOOB && n > 0 {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		s := vec.(*narray.NArray).Data
+		if sp.noise == nil {
+			sp.noise = make([]float64, len(s), len(s))
+		}
+		for k, x := range s {
+			sp.noise[k] += x
+		}
+	}
+	for k := range sp.noise {
+		sp.noise[k] = math.Max(sp.noise[k]/float64(n), 1e-10)
+	}
+	sp.gain = make([]float64, len(sp.noise), len(sp.noise))
+	return nil
+}
+
+// Reset implements the dsp.Resetter interface.
+func (sp *StatProc) Reset() {
+	sp.noise = nil
+	sp.gain = nil
+	sp.scores = nil
+	sp.Proc.Reset()
+}
@@ -0,0 +1,140 @@
+package vad
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/akualab/dsp"
+	narray "github.com/akualab/narray/na64"
+)
+
+// frames returns a source for a sequence of vectors.
+func frames(data [][]float64) dsp.Processer {
+	return dsp.NewProc(defaultBufSize, func(idx int, in ...dsp.Processer) (dsp.Value, error) {
+		if idx < 0 || idx >= len(data) {
+			return nil, dsp.ErrOOB
+		}
+		return narray.NewArray(data[idx], len(data[idx])), nil
+	})
+}
+
+func values(t *testing.T, p dsp.Framer) []float64 {
+	v := []float64{}
+	for i := 0; ; i++ {
+		vec, err := p.Get(i)
+		if err == dsp.ErrOOB {
+			return v
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		v = append(v, vec.(*narray.NArray).Data[0])
+	}
+}
+
+// spectra returns noise spectra with harmonic speech spectra in frames [20,40) and [60,65).
+func spectra() ([][]float64, []bool) {
+	r := rand.New(rand.NewSource(3))
+	data := make([][]float64, 80)
+	speech := make([]bool, 80)
+	for i := range data {
+		data[i] = make([]float64, 64)
+		for k := range data[i] {
+			data[i][k] = r.ExpFloat64()
+		}
+		if (i >= 20 && i < 40) || (i >= 60 && i < 65) {
+			speech[i] = true
+			for k := 4; k < 64; k += 8 {
+				data[i][k] += 200
+			}
+		}
+	}
+	return data, speech
+}
+
+func TestEntropyAndStat(t *testing.T) {
+
+	data, speech := spectra()
+	app := dsp.NewApp("vad")
+	entropy := app.Chain(
+		app.Add("entropy vad", Threshold(0.8, false)),
+		app.Add("entropy", Entropy()),
+		app.Add("spectrum", frames(data)),
+	)
+	stat := app.Chain(
+		app.Add("stat vad", Threshold(1, true)),
+		app.Add("stat", NewStatProc(1)),
+		app.NodeByName("spectrum"),
+	)
+	for _, node := range []dsp.Node{entropy, stat} {
+		d := values(t, node)
+		if len(d) != len(data) {
+			t.Fatalf("%s: expected %d decisions, got %d", node.Name(), len(data), len(d))
+		}
+		for i, v := range d {
+			if (v == Speech) != speech[i] {
+				t.Fatalf("%s: wrong decision for frame %d", node.Name(), i)
+			}
+		}
+	}
+	h := values(t, app.NodeByName("entropy"))
+	for i, v := range h {
+		if v < 0 || v > 1 {
+			t.Fatalf("entropy out of range in frame %d: %f", i, v)
+		}
+	}
+}
+
+func TestHangover(t *testing.T) {
+
+	in := [][]float64{}
+	for _, c := range "0001100000111111000001000" {
+		in = append(in, []float64{float64(c - '0')})
+	}
+	app := dsp.NewApp("hangover")
+	out := app.Chain(
+		app.Add("vad", NewHangoverProc(1, 2)),
+		app.Add("raw", frames(in)),
+	)
+	expected := "0011110001111111100000000"
+	d := values(t, out)
+	for i, v := range d {
+		if v != float64(expected[i]-'0') {
+			t.Fatalf("frame %d: expected %c, got %f", i, expected[i], v)
+		}
+	}
+	segs, err := Segments(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segs) != 2 || segs[0] != (Segment{2, 6}) || segs[1] != (Segment{9, 17}) {
+		t.Fatalf("unexpected segments: %v", segs)
+	}
+	start, end := segs[1].Seconds(80, 8000)
+	if start != 0.09 || end != 0.17 {
+		t.Fatalf("expected segment from 0.09 to 0.17, got %f to %f", start, end)
+	}
+
+	// Drop non-speech frames.
+	feats := make([][]float64, len(in))
+	for i := range feats {
+		feats[i] = []float64{float64(i)}
+	}
+	drop := app.Connect(
+		app.Add("speech frames", NewDropProc()),
+		app.Add("features", frames(feats)),
+		out,
+	)
+	f := values(t, drop)
+	if len(f) != 12 {
+		t.Fatalf("expected 12 speech frames, got %d", len(f))
+	}
+	if f[0] != 2 || f[4] != 9 || f[11] != 16 {
+		t.Fatalf("unexpected frames: %v", f)
+	}
+
+	app.Reset()
+	if len(values(t, drop)) != 12 {
+		t.Fatal("expected 12 speech frames after reset")
+	}
+}
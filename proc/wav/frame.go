@@ -0,0 +1,44 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wav
+
+import "github.com/akualab/dsp"
+
+// View is a read-only view of a frame of samples. The samples are not copied,
+// the view shares the storage of the waveform.
+type View struct {
+	s []float64
+}
+
+// Len returns the number of samples in the view.
+func (v View) Len() int {
+	return len(v.s)
+}
+
+// At returns sample i.
+func (v View) At(i int) float64 {
+	return v.s[i]
+}
+
+// CopyTo copies the samples to dst and returns the number of samples copied.
+func (v View) CopyTo(dst []float64) int {
+	return copy(dst, v.s)
+}
+
+// View returns a read-only view of the frame for the given index.
+// Returns dsp.ErrOOB if the frame is outside of the waveform.
+func (iter *Iter) View(idx int) (View, error) {
+	n := len(iter.wav.Samples)
+	start := idx * iter.stepSize
+	end := start + iter.frameSize
+	if start < 0 || start >= n {
+		return View{}, dsp.ErrOOB
+	}
+	if end < 1 || end > n {
+		return View{}, dsp.ErrOOB
+	}
+	return View{s: iter.wav.Samples[start:end:end]}, nil
+}
@@ -0,0 +1,210 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wav
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/akualab/dsp"
+)
+
+const (
+	formatPCM        = 1
+	formatFloat      = 3
+	formatExtensible = 0xFFFE
+)
+
+// infoKeys maps RIFF INFO chunk IDs to metadata keys.
+// Unknown IDs are stored using the chunk ID as the key.
+var infoKeys = map[string]string{
+	"INAM": "title",
+	"IART": "artist",
+	"ICMT": "comment",
+	"ICRD": "date",
+	"IGNR": "genre",
+	"ISFT": "software",
+	"ICOP": "copyright",
+	"IPRD": "product",
+	"ISBJ": "subject",
+	"IKEY": "keywords",
+	"ISRC": "source",
+	"ITCH": "technician",
+	"IENG": "engineer",
+	"ILNG": "language",
+}
+
+// ReadWAV reads a waveform in RIFF WAVE format. Supports integer PCM (8, 16, 24 and 32 bits)
+// and IEEE float (32 and 64 bits) encodings. Integer samples are scaled to the range [-1, 1).
+// For multichannel files, only the first channel is returned.
+// The strings in the LIST/INFO chunk are stored in the waveform metadata.
+func ReadWAV(r io.Reader, id string) (*Waveform, error) {
+
+	var hdr [12]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, err
+	}
+	if string(hdr[0:4]) != "RIFF" || string(hdr[8:12]) != "WAVE" {
+		return nil, errors.New("not a RIFF WAVE file")
+	}
+
+	var format, channels, bits int
+	var fs float64
+	var samples []float64
+	meta := dsp.Metadata{}
+	for {
+		var ch [8]byte
+		_, err := io.ReadFull(r, ch[:])
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		chID := string(ch[0:4])
+		size := int64(binary.LittleEndian.Uint32(ch[4:8]))
+		data, err := ioutil.ReadAll(io.LimitReader(r, size))
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(data)) < size && chID != "data" {
+			return nil, fmt.Errorf("chunk [%s] is truncated", chID)
+		}
+		// Chunks are word aligned.
+		if size%2 == 1 {
+			io.ReadFull(r, ch[:1])
+		}
+		switch chID {
+		case "fmt ":
+			if len(data) < 16 {
+				return nil, errors.New("bad fmt chunk")
+			}
+			format = int(binary.LittleEndian.Uint16(data[0:2]))
+			channels = int(binary.LittleEndian.Uint16(data[2:4]))
+			fs = float64(binary.LittleEndian.Uint32(data[4:8]))
+			bits = int(binary.LittleEndian.Uint16(data[14:16]))
+			if format == formatExtensible && len(data) >= 26 {
+				format = int(binary.LittleEndian.Uint16(data[24:26]))
+			}
+		case "data":
+			if channels == 0 {
+				return nil, errors.New("data chunk found before fmt chunk")
+			}
+			samples, err = decodeSamples(data, format, channels, bits)
+			if err != nil {
+				return nil, err
+			}
+		case "LIST":
+			if len(data) >= 4 && string(data[0:4]) == "INFO" {
+				readInfo(data[4:], meta)
+			}
+		}
+	}
+	if samples == nil {
+		return nil, errors.New("no data chunk in WAVE file")
+	}
+	meta["channels"] = float64(channels)
+	w := New(id, samples, fs)
+	w.Meta = meta
+	return w, nil
+}
+
+// ReadWAVFile reads a waveform from a RIFF WAVE file. The ID is the file name without the extension.
+// See ReadWAV for details.
+func ReadWAVFile(path string) (*Waveform, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	base := filepath.Base(path)
+	return ReadWAV(f, strings.TrimSuffix(base, filepath.Ext(base)))
+}
+
+func decodeSamples(data []byte, format, channels, bits int) ([]float64, error) {
+
+	bps := bits / 8
+	frameSize := bps * channels
+	if frameSize == 0 {
+		return nil, fmt.Errorf("bad sample size, bits per sample: %d, channels: %d", bits, channels)
+	}
+	n := len(data) / frameSize
+	samples := make([]float64, n, n)
+	for i := 0; i < n; i++ {
+		b := data[i*frameSize : i*frameSize+bps]
+		switch {
+		case format == formatPCM && bits == 8:
+			samples[i] = (float64(b[0]) - 128) / 128
+		case format == formatPCM && bits == 16:
+			samples[i] = float64(int16(binary.LittleEndian.Uint16(b))) / (1 << 15)
+		case format == formatPCM && bits == 24:
+			v := int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24) >> 8
+			samples[i] = float64(v) / (1 << 23)
+		case format == formatPCM && bits == 32:
+			samples[i] = float64(int32(binary.LittleEndian.Uint32(b))) / (1 << 31)
+		case format == formatFloat && bits == 32:
+			samples[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+		case format == formatFloat && bits == 64:
+			samples[i] = math.Float64frombits(binary.LittleEndian.Uint64(b))
+		default:
+			return nil, fmt.Errorf("unsupported WAVE encoding, format: %d, bits per sample: %d", format, bits)
+		}
+	}
+	return samples, nil
+}
+
+// readInfo reads the subchunks of a LIST/INFO chunk.
+func readInfo(data []byte, meta dsp.Metadata) {
+	for len(data) >= 8 {
+		id := string(data[0:4])
+		size := int(binary.LittleEndian.Uint32(data[4:8]))
+		data = data[8:]
+		if size > len(data) {
+			size = len(data)
+		}
+		value := string(bytes.TrimRight(data[:size], "\x00"))
+		key, ok := infoKeys[id]
+		if !ok {
+			key = id
+		}
+		meta[key] = value
+		if size%2 == 1 && size < len(data) {
+			size++
+		}
+		data = data[size:]
+	}
+}
+
+// wavStreamer reads waveforms from a WAVE file. Implements the streamer interface.
+type wavStreamer struct {
+	path string
+	done bool
+}
+
+func (ws *wavStreamer) Next(v interface{}) error {
+	if ws.done {
+		return Done
+	}
+	w, err := ReadWAVFile(ws.path)
+	if err != nil {
+		return err
+	}
+	*(v.(**Waveform)) = w
+	ws.done = true
+	return nil
+}
+
+func (ws *wavStreamer) Close() error {
+	return nil
+}
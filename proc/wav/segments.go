@@ -0,0 +1,182 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wav
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// SegmentFormat is the format of a segmentation file.
+type SegmentFormat int
+
+const (
+	// KaldiSegments is the Kaldi "segments" format:
+	//  <utterance-id> <recording-id> <start-time> <end-time>
+	KaldiSegments SegmentFormat = iota
+	// CTM is the NIST time-marked conversation format:
+	//  <recording-id> <channel> <start-time> <duration> <word> [<confidence>]
+	CTM
+	// RTTM is the NIST rich transcription time-marked format. Only SPEAKER lines are used:
+	//  SPEAKER <recording-id> <channel> <start-time> <duration> <NA> <NA> <speaker> <NA> <NA>
+	RTTM
+)
+
+// Segment is a time interval in a waveform. When a list of segments is
+// attached to a SourceProc, each segment is processed as a separate utterance.
+type Segment struct {
+	// ID is the segment identifier. It is used as the utterance ID.
+	ID string
+	// WavID is the ID of the waveform that contains the segment.
+	WavID string
+	// Channel is the channel name. (Empty for Kaldi segments.)
+	Channel string
+	// Start time in seconds.
+	Start float64
+	// End time in seconds.
+	End float64
+	// Label is the word in CTM files or the speaker name in RTTM files.
+	Label string
+}
+
+// Samples converts the segment boundaries from seconds to sample indices
+// using sampling rate fs. The end index is exclusive.
+func (s Segment) Samples(fs float64) (start, end int) {
+	start = int(math.Floor(s.Start*fs + 0.5))
+	end = int(math.Floor(s.End*fs + 0.5))
+	return
+}
+
+// ReadSegments reads a list of segments from a reader.
+// Empty lines and lines starting with ";;" or "#" are ignored.
+func ReadSegments(r io.Reader, format SegmentFormat) ([]Segment, error) {
+
+	segs := []Segment{}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, ";;") || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		var seg Segment
+		var err error
+		switch format {
+		case KaldiSegments:
+			seg, err = parseKaldiSegment(fields)
+		case CTM:
+			seg, err = parseCTM(fields)
+		case RTTM:
+			if fields[0] != "SPEAKER" {
+				continue
+			}
+			seg, err = parseRTTM(fields)
+		default:
+			return nil, fmt.Errorf("unknown segment format: %d", format)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", n, err)
+		}
+		if seg.End <= seg.Start {
+			return nil, fmt.Errorf("line %d: segment end [%f] must be greater than start [%f]", n, seg.End, seg.Start)
+		}
+		segs = append(segs, seg)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return segs, nil
+}
+
+// ReadSegmentsFile reads a list of segments from a file.
+func ReadSegmentsFile(path string, format SegmentFormat) ([]Segment, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadSegments(f, format)
+}
+
+func parseKaldiSegment(fields []string) (Segment, error) {
+	if len(fields) < 4 {
+		return Segment{}, fmt.Errorf("expected 4 fields in segments line, got %d", len(fields))
+	}
+	start, end, err := parseTimes(fields[2], fields[3], false)
+	if err != nil {
+		return Segment{}, err
+	}
+	return Segment{
+		ID:    fields[0],
+		WavID: fields[1],
+		Start: start,
+		End:   end,
+	}, nil
+}
+
+func parseCTM(fields []string) (Segment, error) {
+	if len(fields) < 5 {
+		return Segment{}, fmt.Errorf("expected at least 5 fields in CTM line, got %d", len(fields))
+	}
+	start, end, err := parseTimes(fields[2], fields[3], true)
+	if err != nil {
+		return Segment{}, err
+	}
+	return Segment{
+		ID:      segmentID(fields[0], fields[1], start, end),
+		WavID:   fields[0],
+		Channel: fields[1],
+		Start:   start,
+		End:     end,
+		Label:   fields[4],
+	}, nil
+}
+
+func parseRTTM(fields []string) (Segment, error) {
+	if len(fields) < 8 {
+		return Segment{}, fmt.Errorf("expected at least 8 fields in RTTM line, got %d", len(fields))
+	}
+	start, end, err := parseTimes(fields[3], fields[4], true)
+	if err != nil {
+		return Segment{}, err
+	}
+	return Segment{
+		ID:      segmentID(fields[1], fields[2], start, end),
+		WavID:   fields[1],
+		Channel: fields[2],
+		Start:   start,
+		End:     end,
+		Label:   fields[7],
+	}, nil
+}
+
+// parseTimes returns start and end times. If isDur is true, the second
+// argument is the duration of the segment.
+func parseTimes(s1, s2 string, isDur bool) (float64, float64, error) {
+	start, err := strconv.ParseFloat(s1, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := strconv.ParseFloat(s2, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	if isDur {
+		end += start
+	}
+	return start, end, nil
+}
+
+// segmentID makes a Kaldi-style segment ID using times in centiseconds.
+func segmentID(wavID, channel string, start, end float64) string {
+	return fmt.Sprintf("%s-%s-%07d-%07d", wavID, channel,
+		int(math.Floor(start*100+0.5)), int(math.Floor(end*100+0.5)))
+}
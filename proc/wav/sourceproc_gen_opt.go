@@ -35,6 +35,24 @@ func WinType(o int) optSourceProc {
 	}
 }
 
+// WinName sets a value for instances of type SourceProc.
+func WinName(o string) optSourceProc {
+	return func(t *SourceProc) optSourceProc {
+		previous := t.winName
+		t.winName = o
+		return WinName(previous)
+	}
+}
+
+// WinSize sets a value for instances of type SourceProc.
+func WinSize(o int) optSourceProc {
+	return func(t *SourceProc) optSourceProc {
+		previous := t.winSize
+		t.winSize = o
+		return WinSize(previous)
+	}
+}
+
 // FrameSize sets a value for instances of type SourceProc.
 func FrameSize(o int) optSourceProc {
 	return func(t *SourceProc) optSourceProc {
@@ -88,3 +106,12 @@ func End(o int) optSourceProc {
 		return End(previous)
 	}
 }
+
+// Segments sets a value for instances of type SourceProc.
+func Segments(o []Segment) optSourceProc {
+	return func(t *SourceProc) optSourceProc {
+		previous := t.segments
+		t.segments = o
+		return Segments(previous)
+	}
+}
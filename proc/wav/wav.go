@@ -6,8 +6,10 @@
 package wav
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/akualab/dsp"
 	"github.com/akualab/dsp/proc"
@@ -18,6 +20,9 @@ import (
 // Done is returned as the error value when there are no more waveforms available in the stream.
 var Done = errors.New("no more json objects")
 
+// defaultFrameCacheSize is the number of windowed frames cached by SourceProc when BufSize is not set.
+const defaultFrameCacheSize = 1000
+
 // A Waveform format for reading json files.
 type Waveform struct {
 	// ID is a waveform identifier.
@@ -26,11 +31,43 @@ type Waveform struct {
 	Samples []float64 `samples:"id"`
 	// FS is the sampling frequency in Hertz.
 	FS float64 `json:"fs,omitempty"`
+	// Meta has additional information about the waveform such as the speaker, channel, or labels.
+	// When reading json, fields other than id, samples and fs are stored in Meta.
+	Meta dsp.Metadata `json:"-"`
 
 	sumx   float64
 	sumxsq float64
 }
 
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (w *Waveform) UnmarshalJSON(b []byte) error {
+	type waveform Waveform
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+	var ww waveform
+	if err := json.Unmarshal(b, &ww); err != nil {
+		return err
+	}
+	*w = Waveform(ww)
+	for k, raw := range fields {
+		switch strings.ToLower(k) {
+		case "id", "samples", "fs":
+			continue
+		}
+		var v interface{}
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		if w.Meta == nil {
+			w.Meta = dsp.Metadata{}
+		}
+		w.Meta[k] = v
+	}
+	return nil
+}
+
 // New returns a waveform object.
 // To specifiy a sampling rate, use option fs. Use fs=0 to ignore checks. (In the future the package will convert the sampling rate.)
 func New(id string, samples []float64, fs float64) *Waveform {
@@ -45,9 +82,15 @@ func New(id string, samples []float64, fs float64) *Waveform {
 	return w
 }
 
+// streamer reads waveforms sequentially.
+type streamer interface {
+	Next(interface{}) error
+	Close() error
+}
+
 // Iter is an iterator to access waveforms sequentially.
 type Iter struct {
-	js                           *ju.JSONStreamer
+	js                           streamer
 	frameSize, stepSize, winType int
 	winData                      []float64
 	fs                           float64
@@ -60,12 +103,18 @@ type Iter struct {
 // The distance between succesive frames is stepSize.
 // To get a single frame form the entire waveform use frameSize=0.
 // If frameSize equals the stepSize, the waveform is partitioned using disjoint segments.
-// To specify path see ju.JSONStreamer.
+// To specify path see ju.JSONStreamer. If path has extension ".wav", the waveform is read from a RIFF WAVE file. (See ReadWAV.)
 // It is the caller's responsibility to call Close to release the underlying readers.
 func NewIterator(path string, fs float64, frameSize, stepSize int) (*Iter, error) {
-	js, err := ju.NewJSONStreamer(path)
-	if err != nil {
-		return nil, err
+	var js streamer
+	if strings.HasSuffix(strings.ToLower(path), ".wav") {
+		js = &wavStreamer{path: path}
+	} else {
+		var err error
+		js, err = ju.NewJSONStreamer(path)
+		if err != nil {
+			return nil, err
+		}
 	}
 	iter := &Iter{
 		js:        js,
@@ -91,31 +140,45 @@ func (iter *Iter) Next() (*Waveform, error) {
 func (iter *Iter) NextSegment(start, end int) (*Waveform, error) {
 	var w *Waveform
 	e := iter.js.Next(&w)
-	if e == ju.Done {
+	if e == ju.Done || e == Done {
 		return w, Done
 	}
+	if e != nil {
+		return nil, e
+	}
 	if w.FS > 0 && iter.fs > 0 && (w.FS != iter.fs) {
-		fmt.Errorf("sampling rates don't match - wav fs is [%f], expected [%f] - TODO: implement sampling rate conversion", w.FS, iter.fs)
+		return nil, fmt.Errorf("sampling rates don't match - wav fs is [%f], expected [%f] - TODO: implement sampling rate conversion", w.FS, iter.fs)
 	}
-	iter.wav, e = getWav(w, start, end, iter.fs)
+	seg, e := getWav(w, start, end, iter.fs)
+	if e != nil {
+		return nil, e
+	}
+	iter.load(seg)
+	return iter.wav, nil
+}
+
+// load makes w the current waveform.
+func (iter *Iter) load(w *Waveform) {
+	iter.wav = w
 	if iter.frameSize < 1 {
 		iter.frameSize = len(iter.wav.Samples)
 		iter.stepSize = iter.frameSize
 	}
-	return iter.wav, e
 }
 
 func getWav(w *Waveform, start, end int, fs float64) (*Waveform, error) {
-	if start >= len(w.Samples) {
-		fmt.Errorf("start must be less than length of wav, got start=%d, len(wav)=%d", start, len(w.Samples))
+	if start < 0 || start >= len(w.Samples) {
+		return nil, fmt.Errorf("start must be less than length of wav, got start=%d, len(wav)=%d", start, len(w.Samples))
 	}
 	if end == -1 {
 		end = len(w.Samples)
 	}
-	if start >= end {
-		fmt.Errorf("start must be less than end, got start=%d, end=%d", start, end)
+	if start >= end || end > len(w.Samples) {
+		return nil, fmt.Errorf("start must be less than end, got start=%d, end=%d, len(wav)=%d", start, end, len(w.Samples))
 	}
-	return New(w.ID, w.Samples[start:end], fs), nil
+	seg := New(w.ID, w.Samples[start:end], fs)
+	seg.Meta = w.Meta
+	return seg, nil
 }
 
 // Close underlying readers.
@@ -136,18 +199,13 @@ func (iter *Iter) NumFrames() int {
 
 // Frame returns a frame of samples for the given index. NOTE: the slice may be shared with
 // other processors or may be cached. For these reason, the caller should not modify the slice in-place.
+// See also View.
 func (iter *Iter) Frame(idx int) (dsp.Value, error) {
-	n := len(iter.wav.Samples)
-	start := idx * iter.stepSize
-	end := start + iter.frameSize
-	if start < 0 || start >= n {
-		return nil, dsp.ErrOOB
-	}
-	if end < 1 || end > n {
-		return nil, dsp.ErrOOB
+	v, err := iter.View(idx)
+	if err != nil {
+		return nil, err
 	}
-	res := narray.NewArray(iter.wav.Samples[start:end], iter.frameSize)
-	return res, nil
+	return narray.NewArray(v.s, v.Len()), nil
 }
 
 // Reset implements the dsp.Resetter interface.
@@ -164,19 +222,31 @@ type SourceProc struct {
 	wav       *Waveform `opt:"-"`
 	zm        bool
 	winType   int
-	winData   []float64 `opt:"-"`
+	winName   string
+	winSize   int
+	winData   []float64              `opt:"-"`
+	frames    map[int]*narray.NArray `opt:"-"`
+	order     []int                  `opt:"-"`
 	frameSize int
 	stepSize  int
 	bufSize   int
 	fs        float64
 	start     int
 	end       int
+	segments  []Segment
+	segIndex  map[string][]Segment `opt:"-"`
+	pending   []Segment            `opt:"-"`
+	rec       *Waveform            `opt:"-"`
+	seg       *Segment             `opt:"-"`
 }
 
 // NewSourceProc create a new source of waveforms.
 // See also New() for more details.
 // If zeroMean is true, the mean of the waveform samples is subtracetd from every sample.
 // Note that calling Mean() will still return the original mean value. Think of Mean() as the original mean value.
+// Use option WinName to set the window by name. (See proc.WindowByName.)
+// Use option WinSize for a window smaller than the frame size, the remaining samples are zero padded.
+// Use option Segments to process a list of segments instead of entire waveforms. (See Next() for details.)
 func NewSourceProc(path string, options ...optSourceProc) (*SourceProc, error) {
 	s := &SourceProc{path: path}
 
@@ -190,18 +260,37 @@ func NewSourceProc(path string, options ...optSourceProc) (*SourceProc, error) {
 	s.iter = iter
 	s.Proc = dsp.NewProc(s.bufSize, nil)
 
+	if len(s.winName) > 0 {
+		s.winType, err = proc.WindowByName(s.winName)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if s.winSize < 1 {
+		s.winSize = s.frameSize
+	}
+	if s.frameSize > 0 && s.winSize > s.frameSize {
+		return nil, fmt.Errorf("window size [%d] is larger than frame size [%d]", s.winSize, s.frameSize)
+	}
 	if s.winType > 0 {
-		s.winData, err = proc.WindowSlice(s.winType, s.frameSize)
+		s.winData, err = proc.WindowSlice(s.winType, s.winSize)
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	if len(s.segments) > 0 {
+		s.segIndex = make(map[string][]Segment)
+		for _, seg := range s.segments {
+			s.segIndex[seg.WavID] = append(s.segIndex[seg.WavID], seg)
+		}
+	}
 	return s, nil
 }
 
 // Rewind makes the the current waveform available for processing with different parameters.
 // This is useful when the a waveform source needs to be segmented in multiple ways.
+// The window size is the smallest of the WinSize option and frameSize.
 func (src *SourceProc) Rewind(start, end, frameSize, stepSize int, winType int) error {
 
 	var err error
@@ -209,12 +298,6 @@ func (src *SourceProc) Rewind(start, end, frameSize, stepSize int, winType int)
 		return fmt.Errorf("source proc has no waveform loaded, cannot rewind, call Next() before attempting to rewind")
 	}
 
-	src.iter.winType = winType
-	src.iter.winData, err = proc.WindowSlice(winType, frameSize)
-	if err != nil {
-		return err
-	}
-
 	src.iter.wav, err = getWav(src.wav, start, end, src.wav.FS)
 	if err != nil {
 		return err
@@ -226,11 +309,29 @@ func (src *SourceProc) Rewind(start, end, frameSize, stepSize int, winType int)
 		src.iter.frameSize = frameSize
 		src.iter.stepSize = stepSize
 	}
+
+	src.iter.winType = winType
+	src.iter.winData = nil
+	if winType > 0 {
+		n := src.iter.frameSize
+		if src.winSize > 0 && src.winSize < n {
+			n = src.winSize
+		}
+		src.iter.winData, err = proc.WindowSlice(winType, n)
+		if err != nil {
+			return err
+		}
+	}
+	src.Reset()
 	return nil
 }
 
 // Next loads a segment of the next available waveform into the source. Returns Done when all waveforms have been processed.
 // See also Waveform.NextSegment() for details.
+//
+// When a list of segments was provided, each call to Next loads the next segment as a separate utterance whose ID is the
+// segment ID. Segments are processed in the order in which they appear in the list for each waveform. Waveforms that
+// have no segments are skipped.
 //func (src *SourceProc) NextSegment(start, end int) error {
 func (src *SourceProc) Next() error {
 	var err error
@@ -239,7 +340,11 @@ func (src *SourceProc) Next() error {
 	src.iter.frameSize = src.frameSize
 	src.iter.stepSize = src.stepSize
 
-	src.wav, err = src.iter.Next()
+	if src.segIndex != nil {
+		src.wav, err = src.nextSegment()
+	} else {
+		src.wav, err = src.iter.Next()
+	}
 	if err == Done {
 		e := src.iter.Close()
 		if e != nil {
@@ -250,6 +355,7 @@ func (src *SourceProc) Next() error {
 	if err != nil {
 		return err
 	}
+	src.Reset()
 	if src.zm {
 		for i := range src.wav.Samples {
 			src.wav.Samples[i] -= src.wav.Mean()
@@ -262,42 +368,160 @@ func (src *SourceProc) Next() error {
 	return nil
 }
 
+// nextSegment returns the next segment as a waveform.
+func (src *SourceProc) nextSegment() (*Waveform, error) {
+	for len(src.pending) == 0 {
+		w, err := src.iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		src.rec = w
+		src.pending = src.segIndex[w.ID]
+	}
+	seg := src.pending[0]
+	src.pending = src.pending[1:]
+	src.seg = &seg
+
+	fs := src.rec.FS
+	if fs == 0 {
+		fs = src.fs
+	}
+	if fs <= 0 {
+		return nil, fmt.Errorf("sampling rate is required to convert segment [%s] times to samples, use option Fs", seg.ID)
+	}
+	// Negative start times are common rounding errors in segment files.
+	start, end := seg.Samples(fs)
+	if start < 0 {
+		start = 0
+	}
+	if end > len(src.rec.Samples) {
+		end = len(src.rec.Samples)
+	}
+	if start >= end {
+		return nil, fmt.Errorf("segment [%s] from %f to %f is outside of waveform [%s] with %d samples", seg.ID, seg.Start, seg.End, seg.WavID, len(src.rec.Samples))
+	}
+
+	// Segments may overlap so we need a copy if samples will be modified.
+	samples := src.rec.Samples[start:end]
+	if src.zm {
+		samples = make([]float64, end-start)
+		copy(samples, src.rec.Samples[start:end])
+	}
+	w := New(seg.ID, samples, fs)
+	w.Meta = dsp.Metadata{}
+	for k, v := range src.rec.Meta {
+		w.Meta[k] = v
+	}
+	w.Meta["wav_id"] = seg.WavID
+	w.Meta["start"] = seg.Start
+	w.Meta["end"] = seg.End
+	if len(seg.Channel) > 0 {
+		w.Meta["channel"] = seg.Channel
+	}
+	if len(seg.Label) > 0 {
+		w.Meta["label"] = seg.Label
+	}
+
+	// Loading the recording changed the frame size when it is zero.
+	src.iter.frameSize = src.frameSize
+	src.iter.stepSize = src.stepSize
+	src.iter.load(w)
+	return w, nil
+}
+
+// Segment returns the segment currently loaded in the source. Returns false if the
+// source is not processing a list of segments.
+func (src *SourceProc) Segment() (Segment, bool) {
+	if src.seg == nil {
+		return Segment{}, false
+	}
+	return *src.seg, true
+}
+
 // Get implements the dsp.Processer interface.
+// Without a window, the value shares storage with the waveform samples and must not be modified: a change
+// modifies the waveform and every frame that overlaps it. Copy the values to modify them.
 // If window option is used, window size must be less or equal than frameSize. If smaller, remaining samples are zero padded.
+// Windowed frames are new vectors that don't share storage with the waveform. The last bufSize windowed frames are cached.
 func (src *SourceProc) Get(idx int) (dsp.Value, error) {
-	in, err := src.iter.Frame(idx)
+	if v, ok := src.frames[idx]; ok {
+		return v, nil
+	}
+	view, err := src.iter.View(idx)
 	if err != nil {
 		return nil, err
 	}
-	// No windowing, we are done.
-	if src.iter.frameSize == 0 {
-		return in, nil
-	}
-	na := in.(*narray.NArray)
-	inSize := na.Shape[0]
-	if src.iter.frameSize > inSize {
-		return nil, fmt.Errorf("window size [%d] is larger than input vector size [%d]", src.iter.frameSize, inSize)
+	n := len(src.iter.winData)
+	if src.iter.winType == 0 || n == 0 {
+		// No windowing, zero-copy.
+		return narray.NewArray(view.s, view.Len()), nil
 	}
-	v := narray.New(inSize)
-	if src.iter.winType > 0 {
-		for i, w := range src.iter.winData {
-			v.Data[i] = na.Data[i] * w
-		}
-	} else {
-		copy(v.Data, na.Data)
+	if n > view.Len() {
+		return nil, fmt.Errorf("window size [%d] is larger than frame size [%d]", n, view.Len())
 	}
-	// Zero padding.
-	for i := src.iter.frameSize; i < inSize; i++ {
-		na.Data[i] = 0
+
+	// Samples beyond the window size remain zero.
+	buf := make([]float64, view.Len(), view.Len())
+	for i, w := range src.iter.winData {
+		buf[i] = view.s[i] * w
 	}
+	v := narray.NewArray(buf, len(buf))
+	src.cacheFrame(idx, v)
 	return v, nil
 }
 
+// cacheFrame adds a windowed frame to the cache. When the cache is full, the
+// oldest frame is removed.
+func (src *SourceProc) cacheFrame(idx int, v *narray.NArray) {
+	if src.frames == nil {
+		src.frames = make(map[int]*narray.NArray)
+	}
+	size := src.bufSize
+	if size < 1 {
+		size = defaultFrameCacheSize
+	}
+	for len(src.order) >= size {
+		old := src.order[0]
+		src.order = src.order[1:]
+		delete(src.frames, old)
+	}
+	src.frames[idx] = v
+	src.order = append(src.order, idx)
+}
+
+// Reset implements the dsp.Resetter interface.
+func (src *SourceProc) Reset() {
+	src.frames = nil
+	src.order = src.order[:0]
+	src.ClearCache()
+}
+
 // ID returns the id of the current waveform.
 func (src *SourceProc) ID() string {
 	return src.wav.ID
 }
 
+// Meta returns the metadata of the current waveform.
+func (src *SourceProc) Meta() dsp.Metadata {
+	return src.wav.Meta
+}
+
+// NewContext returns a stream context for the current waveform. Use it to
+// pass the waveform ID and metadata to the processors in an app:
+//  app.SetContext(src.NewContext())
+func (src *SourceProc) NewContext() *dsp.Context {
+	meta := dsp.Metadata{}
+	for k, v := range src.wav.Meta {
+		meta[k] = v
+	}
+	meta["fs"] = src.wav.FS
+	meta["num_samples"] = float64(len(src.wav.Samples))
+	return &dsp.Context{
+		ID:   src.wav.ID,
+		Meta: meta,
+	}
+}
+
 // NumFrames returns the number of frames in the current waveform.
 func (src *SourceProc) NumFrames() int {
 	return src.iter.NumFrames()
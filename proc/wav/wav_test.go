@@ -1,8 +1,12 @@
 package wav
 
 import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/akualab/dsp"
@@ -151,3 +155,403 @@ func ExampleNewSourceProc_spectrum() {
 	// Output:
 	// 5.123420120893221e-05
 }
+
+func TestSegments(t *testing.T) {
+
+	kaldi := `
+seg-a wav1 0.00 0.25
+seg-b wav1 0.20 0.50
+seg-c wav2 0.5 1.0
+seg-d wav2 -0.01 0.1
+`
+	segs, err := ReadSegments(strings.NewReader(kaldi), KaldiSegments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := NewSourceProc(dir, Fs(8000), Segments(segs), FrameSize(80), StepSize(80))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []struct {
+		id         string
+		numSamples int
+	}{{"seg-a", 2000}, {"seg-b", 2400}, {"seg-c", 4000}, {"seg-d", 800}}
+	for i := 0; ; i++ {
+		err := src.Next()
+		if err == Done {
+			if i != len(expected) {
+				t.Fatalf("expected %d segments, got %d", len(expected), i)
+			}
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		seg, ok := src.Segment()
+		if !ok {
+			t.Fatal("expected a segment")
+		}
+		t.Log(i, src.ID(), seg.WavID, src.NumSamples(), src.NumFrames())
+		if src.ID() != expected[i].id {
+			t.Fatalf("expected id %s, got %s", expected[i].id, src.ID())
+		}
+		if src.NumSamples() != expected[i].numSamples {
+			t.Fatalf("expected %d samples, got %d", expected[i].numSamples, src.NumSamples())
+		}
+		if src.NumFrames() != expected[i].numSamples/80 {
+			t.Fatalf("expected %d frames, got %d", expected[i].numSamples/80, src.NumFrames())
+		}
+	}
+}
+
+func TestReadSegments(t *testing.T) {
+
+	ctm := `;; comment
+wav1 A 0.10 0.20 hello 0.9
+wav1 A 0.30 0.15 world
+`
+	segs, err := ReadSegments(strings.NewReader(ctm), CTM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segs) != 2 {
+		t.Fatalf("expected 2 segments, got %d", len(segs))
+	}
+	if segs[1].ID != "wav1-A-0000030-0000045" || segs[1].Label != "world" {
+		t.Fatalf("bad segment: %+v", segs[1])
+	}
+	start, end := segs[1].Samples(8000)
+	if start != 2400 || end != 3600 {
+		t.Fatalf("expected samples [2400,3600), got [%d,%d)", start, end)
+	}
+
+	rttm := `SPEAKER wav2 1 1.50 0.50 <NA> <NA> spk1 <NA> <NA>
+SPKR-INFO wav2 1 <NA> <NA> <NA> unknown spk1 <NA> <NA>
+SPEAKER wav2 1 2.00 1.00 <NA> <NA> spk2 <NA> <NA>
+`
+	segs, err = ReadSegments(strings.NewReader(rttm), RTTM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segs) != 2 {
+		t.Fatalf("expected 2 segments, got %d", len(segs))
+	}
+	if segs[0].Label != "spk1" || segs[1].End != 3.0 {
+		t.Fatalf("bad segments: %+v", segs)
+	}
+
+	_, err = ReadSegments(strings.NewReader("seg wav1 0.5 0.2\n"), KaldiSegments)
+	if err == nil {
+		t.Fatal("expected error for segment with end < start")
+	}
+}
+
+func TestSourceProcWindow(t *testing.T) {
+
+	path := filepath.Join(dir, "wav1.json.gz")
+	src, err := NewSourceProc(path, Fs(8000), FrameSize(205), StepSize(80), WinType(proc.Hamming))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := src.Next(); err != nil {
+		t.Fatal(err)
+	}
+	win := proc.HammingWindow(205)
+	orig := make([]float64, 205)
+	copy(orig, src.wav.Samples[800:1005])
+	v, err := src.Get(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	na := v.(*narray.NArray)
+	for i := range win {
+		if na.Data[i] != orig[i]*win[i] {
+			t.Fatalf("mismatch at %d - want %g, got %g", i, orig[i]*win[i], na.Data[i])
+		}
+		if src.wav.Samples[800+i] != orig[i] {
+			t.Fatalf("waveform was modified at sample %d", 800+i)
+		}
+	}
+	v2, _ := src.Get(10)
+	if v2.(*narray.NArray) != na {
+		t.Fatal("expected cached frame")
+	}
+
+	// Window smaller than frame: remaining samples are zero padded.
+	src, err = NewSourceProc(path, Fs(8000), FrameSize(256), WinSize(205), StepSize(80), WinType(proc.Hamming), BufSize(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := src.Next(); err != nil {
+		t.Fatal(err)
+	}
+	v, err = src.Get(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	na = v.(*narray.NArray)
+	if len(na.Data) != 256 {
+		t.Fatalf("expected frame size 256, got %d", len(na.Data))
+	}
+	for i := range win {
+		if na.Data[i] != orig[i]*win[i] {
+			t.Fatalf("mismatch at %d - want %g, got %g", i, orig[i]*win[i], na.Data[i])
+		}
+	}
+	for i := 205; i < 256; i++ {
+		if na.Data[i] != 0 {
+			t.Fatalf("expected zero padding at %d, got %g", i, na.Data[i])
+		}
+	}
+
+	// Only the last BufSize frames are cached.
+	for i := 11; i < 14; i++ {
+		if _, err := src.Get(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(src.frames) != 2 {
+		t.Fatalf("expected 2 cached frames, got %d", len(src.frames))
+	}
+	v2, _ = src.Get(10)
+	if v2.(*narray.NArray) == na {
+		t.Fatal("expected frame to be removed from the cache")
+	}
+	// Frames removed from the cache are not reused.
+	src.Reset()
+	for i := 0; i < 5; i++ {
+		src.Get(i)
+	}
+	for i := range win {
+		if na.Data[i] != orig[i]*win[i] {
+			t.Fatalf("frame was overwritten at %d - want %g, got %g", i, orig[i]*win[i], na.Data[i])
+		}
+	}
+
+	if _, err := NewSourceProc(path, FrameSize(200), WinSize(205), WinType(proc.Hamming)); err == nil {
+		t.Fatal("expected error for window larger than frame")
+	}
+}
+
+func TestSourceProcWinName(t *testing.T) {
+
+	path := filepath.Join(dir, "wav1.json.gz")
+	src, err := NewSourceProc(path, Fs(8000), FrameSize(205), StepSize(80), WinName("povey-sym"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src.winType != proc.Povey|proc.Symmetric {
+		t.Fatalf("expected window type %d, got %d", proc.Povey|proc.Symmetric, src.winType)
+	}
+	win := proc.PoveyWindow(205, true)
+	for i := range win {
+		if src.winData[i] != win[i] {
+			t.Fatalf("mismatch at %d - want %g, got %g", i, win[i], src.winData[i])
+		}
+	}
+	if _, err := NewSourceProc(path, WinName("foo")); err == nil {
+		t.Fatal("expected error for unknown window name")
+	}
+}
+
+func TestSourceProcView(t *testing.T) {
+
+	path := filepath.Join(dir, "wav1.json.gz")
+	src, err := NewSourceProc(path, Fs(8000), FrameSize(80), StepSize(80))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := src.Next(); err != nil {
+		t.Fatal(err)
+	}
+	view, err := src.iter.View(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if view.Len() != 80 || view.At(0) != src.wav.Samples[240] {
+		t.Fatalf("bad view, len: %d", view.Len())
+	}
+	v, err := src.Get(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if &v.(*narray.NArray).Data[0] != &src.wav.Samples[240] {
+		t.Fatal("expected frame to share storage with waveform")
+	}
+}
+
+func benchmarkSourceProc(b *testing.B, get func(src *SourceProc, idx int) (dsp.Value, error), options ...optSourceProc) {
+	path := filepath.Join(dir, "wav2.json.gz")
+	src, err := NewSourceProc(path, options...)
+	if err != nil {
+		b.Fatal(err)
+	}
+	if err := src.Next(); err != nil {
+		b.Fatal(err)
+	}
+	nf := src.NumFrames()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		src.Reset()
+		for j := 0; j < nf; j++ {
+			if _, err := get(src, j); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// copyGet copies every frame; used as a reference in benchmarks.
+func copyGet(src *SourceProc, idx int) (dsp.Value, error) {
+	in, err := src.iter.Frame(idx)
+	if err != nil {
+		return nil, err
+	}
+	na := in.(*narray.NArray)
+	v := narray.New(na.Shape[0])
+	if src.iter.winType > 0 {
+		for i, w := range src.iter.winData {
+			v.Data[i] = na.Data[i] * w
+		}
+	} else {
+		copy(v.Data, na.Data)
+	}
+	return v, nil
+}
+
+func BenchmarkSourceProcGet(b *testing.B) {
+	benchmarkSourceProc(b, (*SourceProc).Get, Fs(8000), FrameSize(205), StepSize(80))
+}
+
+func BenchmarkSourceProcGetCopy(b *testing.B) {
+	benchmarkSourceProc(b, copyGet, Fs(8000), FrameSize(205), StepSize(80))
+}
+
+func BenchmarkSourceProcGetWindowed(b *testing.B) {
+	benchmarkSourceProc(b, (*SourceProc).Get, Fs(8000), FrameSize(205), StepSize(80), WinType(proc.Hamming))
+}
+
+func BenchmarkSourceProcGetWindowedCopy(b *testing.B) {
+	benchmarkSourceProc(b, copyGet, Fs(8000), FrameSize(205), StepSize(80), WinType(proc.Hamming))
+}
+
+func BenchmarkSourceProcGetWaveform(b *testing.B) {
+	benchmarkSourceProc(b, (*SourceProc).Get, Fs(8000))
+}
+
+func BenchmarkSourceProcGetWaveformCopy(b *testing.B) {
+	benchmarkSourceProc(b, copyGet, Fs(8000))
+}
+
+func TestWaveformMeta(t *testing.T) {
+
+	js := `{"id":"utt1","samples":[0.1,0.2],"fs":8000,"speaker":"spk7","labels":["a","b"]}`
+	var w *Waveform
+	if err := json.Unmarshal([]byte(js), &w); err != nil {
+		t.Fatal(err)
+	}
+	if w.ID != "utt1" || len(w.Samples) != 2 || w.FS != 8000 {
+		t.Fatalf("bad waveform: %+v", w)
+	}
+	spk, ok := w.Meta.String("speaker")
+	if !ok || spk != "spk7" {
+		t.Fatalf("expected speaker spk7, got %v", w.Meta)
+	}
+	if len(w.Meta["labels"].([]interface{})) != 2 {
+		t.Fatalf("expected two labels, got %v", w.Meta["labels"])
+	}
+	if _, ok := w.Meta["id"]; ok {
+		t.Fatal("id must not be stored in metadata")
+	}
+}
+
+// riffChunk returns a RIFF chunk.
+func riffChunk(id string, data []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(id)
+	binary.Write(&buf, binary.LittleEndian, uint32(len(data)))
+	buf.Write(data)
+	if len(data)%2 == 1 {
+		buf.WriteByte(0)
+	}
+	return buf.Bytes()
+}
+
+func TestReadWAV(t *testing.T) {
+
+	samples := []int16{0, 16384, -16384, -32768}
+	var fmtChunk, data, info bytes.Buffer
+	binary.Write(&fmtChunk, binary.LittleEndian, []uint16{formatPCM, 1})
+	binary.Write(&fmtChunk, binary.LittleEndian, []uint32{16000, 32000})
+	binary.Write(&fmtChunk, binary.LittleEndian, []uint16{2, 16})
+	binary.Write(&data, binary.LittleEndian, samples)
+	info.WriteString("INFO")
+	info.Write(riffChunk("INAM", []byte("utterance one\x00")))
+	info.Write(riffChunk("IART", []byte("spk1\x00")))
+
+	var body bytes.Buffer
+	body.WriteString("WAVE")
+	body.Write(riffChunk("fmt ", fmtChunk.Bytes()))
+	body.Write(riffChunk("LIST", info.Bytes()))
+	body.Write(riffChunk("data", data.Bytes()))
+
+	w, err := ReadWAV(bytes.NewReader(riffChunk("RIFF", body.Bytes())), "utt1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.FS != 16000 {
+		t.Fatalf("expected fs 16000, got %f", w.FS)
+	}
+	expected := []float64{0, 0.5, -0.5, -1}
+	if len(w.Samples) != len(expected) {
+		t.Fatalf("expected %d samples, got %d", len(expected), len(w.Samples))
+	}
+	for i, v := range expected {
+		if w.Samples[i] != v {
+			t.Fatalf("sample %d - expected %f, got %f", i, v, w.Samples[i])
+		}
+	}
+	if title, _ := w.Meta.String("title"); title != "utterance one" {
+		t.Fatalf("expected title [utterance one], got [%s]", title)
+	}
+	if artist, _ := w.Meta.String("artist"); artist != "spk1" {
+		t.Fatalf("expected artist [spk1], got [%s]", artist)
+	}
+}
+
+func TestSourceProcContext(t *testing.T) {
+
+	segs := []Segment{{ID: "seg1", WavID: "wav2", Start: 0.1, End: 0.2, Label: "spk3"}}
+	src, err := NewSourceProc(dir, Fs(8000), Segments(segs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := dsp.NewApp("context")
+	app.Add("wav", src)
+	if err := src.Next(); err != nil {
+		t.Fatal(err)
+	}
+	app.SetContext(src.NewContext())
+	ctx := src.Context()
+	if ctx == nil || ctx.ID != "seg1" {
+		t.Fatalf("bad context: %+v", ctx)
+	}
+	if label, _ := ctx.Meta.String("label"); label != "spk3" {
+		t.Fatalf("expected label spk3, got %v", ctx.Meta)
+	}
+	if wavID, _ := ctx.Meta.String("wav_id"); wavID != "wav2" {
+		t.Fatalf("expected wav_id wav2, got %v", ctx.Meta)
+	}
+
+	// With frame size zero, frame 0 is the whole segment.
+	if src.NumFrames() != 1 {
+		t.Fatalf("expected 1 frame, got %d", src.NumFrames())
+	}
+	v, err := src.Get(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(v.(*narray.NArray).Data); n != 800 {
+		t.Fatalf("expected 800 samples, got %d", n)
+	}
+}
@@ -8,6 +8,9 @@ package proc
 import (
 	"fmt"
 	"math"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/akualab/dsp"
 	narray "github.com/akualab/narray/na64"
@@ -22,8 +25,30 @@ const (
 	Hamming
 	// Blackman window.
 	Blackman
+	// Kaiser window with beta=8.6. Use WindowByName to set beta, for example "kaiser(5)".
+	Kaiser
+	// Gaussian window with sigma=0.4. Use WindowByName to set sigma, for example "gaussian(0.3)".
+	Gaussian
+	// Tukey window with alpha=0.5. Use WindowByName to set alpha, for example "tukey(0.25)".
+	Tukey
+	// Bartlett (triangular) window.
+	Bartlett
+	// FlatTop window.
+	FlatTop
+	// Nuttall window.
+	Nuttall
+	// BlackmanHarris is the 4-term Blackman-Harris window.
+	BlackmanHarris
+	// Povey window. (Used in Kaldi.)
+	Povey
 )
 
+// Symmetric can be combined with a window type to get the symmetric version of the window,
+// for example Hamming|Symmetric. By default, windows are periodic, that is, the window of size N
+// is the symmetric window of size N+1 without the last value. Periodic windows are used for
+// spectral analysis, symmetric windows are used for filter design. (Kaldi uses symmetric windows.)
+const Symmetric = 1 << 16
+
 // WindowProc is a window processor.
 type WindowProc struct {
 	StepSize   int
@@ -46,21 +71,7 @@ func NewWindowProc(stepSize, winSize, windowType int, centered bool) *WindowProc
 		Centered:   centered,
 		Proc:       dsp.NewProc(defaultBufSize, nil),
 	}
-
-	win.WindowType = windowType
-	switch windowType {
-
-	case Rectangular:
-		win.data = RectangularWindow(win.WinSize)
-	case Hanning:
-		win.data = HanningWindow(win.WinSize)
-	case Hamming:
-		win.data = HammingWindow(win.WinSize)
-	case Blackman:
-		win.data = BlackmanWindow(win.WinSize)
-	default:
-		win.err = fmt.Errorf("Unknow window type: %d", windowType)
-	}
+	win.data, win.err = WindowSlice(windowType, winSize)
 	return win
 }
 
@@ -116,24 +127,291 @@ func (win *WindowProc) Get(idx int) (dsp.Value, error) {
 }
 
 // WindowSlice Returns a window as a slice of float64.
+// The window type can be combined with Symmetric. Use WindowByName to get
+// the type of a window by name.
 func WindowSlice(winType, winSize int) ([]float64, error) {
+	f, ok := windowFunc(winType &^ Symmetric)
+	if !ok {
+		return nil, fmt.Errorf("Unknow window type: %d", winType)
+	}
+	return f(winSize, winType&Symmetric != 0), nil
+}
+
+// WindowFunc returns a window of size n.
+type WindowFunc func(n int, symmetric bool) []float64
+
+type windowEntry struct {
+	name string
+	f    WindowFunc
+}
+
+// windows is the window registry. The window type is the index in the list.
+var windows = struct {
+	sync.RWMutex
+	list   []windowEntry
+	byName map[string]int
+}{
+	list: []windowEntry{
+		{"rectangular", func(n int, sym bool) []float64 { return RectangularWindow(n) }},
+		{"hanning", func(n int, sym bool) []float64 {
+			if !sym {
+				return HanningWindow(n)
+			}
+			return CosineSumWindow(n, sym, 0.5, 0.5)
+		}},
+		{"hamming", func(n int, sym bool) []float64 {
+			if !sym {
+				return HammingWindow(n)
+			}
+			return CosineSumWindow(n, sym, 0.54, 0.46)
+		}},
+		{"blackman", func(n int, sym bool) []float64 {
+			if !sym {
+				return BlackmanWindow(n)
+			}
+			return CosineSumWindow(n, sym, 0.42, 0.5, 0.08)
+		}},
+		{"kaiser", windowParams["kaiser"](8.6)},
+		{"gaussian", windowParams["gaussian"](0.4)},
+		{"tukey", windowParams["tukey"](0.5)},
+		{"bartlett", BartlettWindow},
+		{"flattop", func(n int, sym bool) []float64 {
+			return CosineSumWindow(n, sym, 0.21557895, 0.41663158, 0.277263158, 0.083578947, 0.006947368)
+		}},
+		{"nuttall", func(n int, sym bool) []float64 {
+			return CosineSumWindow(n, sym, 0.3635819, 0.4891775, 0.1365995, 0.0106411)
+		}},
+		{"blackmanharris", func(n int, sym bool) []float64 {
+			return CosineSumWindow(n, sym, 0.35875, 0.48829, 0.14128, 0.01168)
+		}},
+		{"povey", PoveyWindow},
+	},
+	byName: map[string]int{
+		"rectangular": Rectangular, "hanning": Hanning, "hann": Hanning, "hamming": Hamming,
+		"blackman": Blackman, "kaiser": Kaiser, "gaussian": Gaussian, "tukey": Tukey,
+		"bartlett": Bartlett, "flattop": FlatTop, "nuttall": Nuttall,
+		"blackmanharris": BlackmanHarris, "povey": Povey,
+	},
+}
+
+// windowParams has the windows that take a parameter.
+var windowParams = map[string]func(float64) WindowFunc{
+	"kaiser": func(beta float64) WindowFunc {
+		return func(n int, sym bool) []float64 { return KaiserWindow(n, beta, sym) }
+	},
+	"gaussian": func(sigma float64) WindowFunc {
+		return func(n int, sym bool) []float64 { return GaussianWindow(n, sigma, sym) }
+	},
+	"tukey": func(alpha float64) WindowFunc {
+		return func(n int, sym bool) []float64 { return TukeyWindow(n, alpha, sym) }
+	},
+}
+
+func windowFunc(winType int) (WindowFunc, bool) {
+	windows.RLock()
+	defer windows.RUnlock()
+	if winType < 0 || winType >= len(windows.list) {
+		return nil, false
+	}
+	return windows.list[winType].f, true
+}
+
+// RegisterWindow adds a window to the registry and returns the window type.
+// Returns an error if the name is already registered.
+func RegisterWindow(name string, f WindowFunc) (int, error) {
+	windows.Lock()
+	defer windows.Unlock()
+	if _, ok := windows.byName[name]; ok {
+		return 0, fmt.Errorf("window [%s] is already registered", name)
+	}
+	windows.list = append(windows.list, windowEntry{name, f})
+	t := len(windows.list) - 1
+	windows.byName[name] = t
+	return t, nil
+}
+
+/*
+WindowByName returns the window type for a name. The names of the predefined windows are
+"rectangular", "hanning" (or "hann"), "hamming", "blackman", "kaiser", "gaussian", "tukey",
+"bartlett", "flattop", "nuttall", "blackmanharris" and "povey".
 
-	switch winType {
-	case Rectangular:
-		s := make([]float64, winSize, winSize)
-		for i := range s {
-			s[i] = 1
+The parameter of the Kaiser, Gaussian and Tukey windows can be set in parenthesis, for example
+"kaiser(5)". A new window type is registered the first time a parameter value is used.
+Add the suffix "-sym" to get the symmetric window, for example "hamming-sym".
+*/
+func WindowByName(name string) (int, error) {
+	var flags int
+	if strings.HasSuffix(name, "-sym") {
+		name = strings.TrimSuffix(name, "-sym")
+		flags = Symmetric
+	}
+	windows.RLock()
+	t, ok := windows.byName[name]
+	windows.RUnlock()
+	if ok {
+		return t | flags, nil
+	}
+	i := strings.Index(name, "(")
+	if i < 0 || !strings.HasSuffix(name, ")") {
+		return 0, fmt.Errorf("unknown window name: %s", name)
+	}
+	newFunc, ok := windowParams[name[:i]]
+	if !ok {
+		return 0, fmt.Errorf("window [%s] does not take a parameter", name[:i])
+	}
+	p, err := strconv.ParseFloat(name[i+1:len(name)-1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("bad window parameter in [%s]: %s", name, err)
+	}
+	windows.Lock()
+	defer windows.Unlock()
+	if t, ok := windows.byName[name]; ok {
+		return t | flags, nil
+	}
+	windows.list = append(windows.list, windowEntry{name, newFunc(p)})
+	t = len(windows.list) - 1
+	windows.byName[name] = t
+	return t | flags, nil
+}
+
+// WindowName returns the name of a window type.
+func WindowName(winType int) string {
+	windows.RLock()
+	defer windows.RUnlock()
+	t := winType &^ Symmetric
+	if t < 0 || t >= len(windows.list) {
+		return fmt.Sprintf("unknown(%d)", winType)
+	}
+	if winType&Symmetric != 0 {
+		return windows.list[t].name + "-sym"
+	}
+	return windows.list[t].name
+}
+
+// denominator returns the denominator used to compute window values.
+func denominator(n int, symmetric bool) float64 {
+	if symmetric {
+		return float64(n - 1)
+	}
+	return float64(n)
+}
+
+// CosineSumWindow returns a generalized cosine window.
+// w(t) = a0 - a1 * cos(2 pi t / T) + a2 * cos(4 pi t / T) - ...
+// where T is n for periodic windows and n-1 for symmetric windows.
+func CosineSumWindow(n int, symmetric bool, a ...float64) []float64 {
+	data := make([]float64, n, n)
+	if n == 1 {
+		data[0] = 1
+		return data
+	}
+	d := denominator(n, symmetric)
+	for i := 0; i < n; i++ {
+		sign := 1.0
+		for k, ak := range a {
+			data[i] += sign * ak * math.Cos(2.0*math.Pi*float64(k*i)/d)
+			sign = -sign
 		}
-		return s, nil
-	case Hanning:
-		return HanningWindow(winSize), nil
-	case Hamming:
-		return HammingWindow(winSize), nil
-	case Blackman:
-		return BlackmanWindow(winSize), nil
-	default:
-		return nil, fmt.Errorf("Unknow window type: %d", winType)
 	}
+	return data
+}
+
+// KaiserWindow returns a Kaiser window.
+// w(t) = I0(beta * sqrt(1 - (2t/T - 1)^2)) / I0(beta)
+// where I0 is the zeroth order modified Bessel function of the first kind.
+func KaiserWindow(n int, beta float64, symmetric bool) []float64 {
+	data := make([]float64, n, n)
+	if n == 1 {
+		data[0] = 1
+		return data
+	}
+	d := denominator(n, symmetric)
+	for i := 0; i < n; i++ {
+		x := 2*float64(i)/d - 1
+		data[i] = besselI0(beta*math.Sqrt(math.Max(0, 1-x*x))) / besselI0(beta)
+	}
+	return data
+}
+
+// besselI0 computes the zeroth order modified Bessel function of the first kind using its power series.
+func besselI0(x float64) float64 {
+	sum, term := 1.0, 1.0
+	for k := 1; k < 500; k++ {
+		term *= (x / (2 * float64(k))) * (x / (2 * float64(k)))
+		sum += term
+		if term < sum*1e-17 {
+			break
+		}
+	}
+	return sum
+}
+
+// GaussianWindow returns a Gaussian window.
+// w(t) = exp(-0.5 * ((t - T/2) / (sigma * T/2))^2)
+func GaussianWindow(n int, sigma float64, symmetric bool) []float64 {
+	data := make([]float64, n, n)
+	if n == 1 {
+		data[0] = 1
+		return data
+	}
+	h := denominator(n, symmetric) / 2
+	for i := 0; i < n; i++ {
+		x := (float64(i) - h) / (sigma * h)
+		data[i] = math.Exp(-0.5 * x * x)
+	}
+	return data
+}
+
+// TukeyWindow returns a Tukey (tapered cosine) window. The fraction alpha of the window is
+// inside the cosine tapers. Use alpha=0 for a rectangular window and alpha=1 for a Hanning window.
+func TukeyWindow(n int, alpha float64, symmetric bool) []float64 {
+	data := make([]float64, n, n)
+	if n == 1 || alpha <= 0 {
+		for i := range data {
+			data[i] = 1
+		}
+		return data
+	}
+	alpha = math.Min(alpha, 1)
+	d := denominator(n, symmetric)
+	w := alpha * d / 2
+	for i := 0; i < n; i++ {
+		t := float64(i)
+		switch {
+		case t < w:
+			data[i] = 0.5 * (1 - math.Cos(math.Pi*t/w))
+		case t > d-w:
+			data[i] = 0.5 * (1 - math.Cos(math.Pi*(d-t)/w))
+		default:
+			data[i] = 1
+		}
+	}
+	return data
+}
+
+// BartlettWindow returns a Bartlett (triangular) window.
+// w(t) = 1 - |2t/T - 1|
+func BartlettWindow(n int, symmetric bool) []float64 {
+	data := make([]float64, n, n)
+	if n == 1 {
+		data[0] = 1
+		return data
+	}
+	d := denominator(n, symmetric)
+	for i := 0; i < n; i++ {
+		data[i] = 1 - math.Abs(2*float64(i)/d-1)
+	}
+	return data
+}
+
+// PoveyWindow returns the window used in Kaldi. Similar to a Hamming window but goes to zero at the edges.
+// w(t) = (0.5  – 0.5 * cos(2 pi t / T))^0.85
+func PoveyWindow(n int, symmetric bool) []float64 {
+	data := CosineSumWindow(n, symmetric, 0.5, 0.5)
+	for i, v := range data {
+		data[i] = math.Pow(math.Max(v, 0), 0.85)
+	}
+	return data
 }
 
 // RectangularWindow returns a rectangular window.
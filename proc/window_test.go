@@ -0,0 +1,118 @@
+package proc
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWindows(t *testing.T) {
+
+	compareFloats(t, 1.2660658777520082, besselI0(1), "I0", 1e-14)
+
+	for _, c := range []struct {
+		typ      int
+		expected []float64
+	}{
+		{Bartlett | Symmetric, []float64{0, 0.5, 1, 0.5, 0}},
+		{Bartlett, []float64{0, 0.4, 0.8, 0.8, 0.4}},
+		{Tukey | Symmetric, []float64{0, 1, 1, 1, 0}},
+		{Hanning | Symmetric, []float64{0, 0.5, 1, 0.5, 0}},
+		{Hamming | Symmetric, []float64{0.08, 0.54, 1, 0.54, 0.08}},
+		{Povey | Symmetric, []float64{0, math.Pow(0.5, 0.85), 1, math.Pow(0.5, 0.85), 0}},
+		{Rectangular | Symmetric, []float64{1, 1, 1, 1, 1}},
+	} {
+		w, err := WindowSlice(c.typ, 5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		compareSliceFloat(t, c.expected, w, WindowName(c.typ), 1e-12)
+	}
+
+	// Peak value at the center of the symmetric windows.
+	for _, typ := range []int{Kaiser, Gaussian, FlatTop, Nuttall, BlackmanHarris, Blackman} {
+		w, err := WindowSlice(typ|Symmetric, 9)
+		if err != nil {
+			t.Fatal(err)
+		}
+		compareFloats(t, 1, w[4], WindowName(typ), 1e-6)
+	}
+	w := KaiserWindow(9, 8.6, true)
+	compareFloats(t, 1/besselI0(8.6), w[0], "kaiser edge", 1e-12)
+
+	for typ := Rectangular; typ <= Povey; typ++ {
+		// Symmetric windows.
+		sym, err := WindowSlice(typ|Symmetric, 11)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range sym {
+			compareFloats(t, sym[i], sym[len(sym)-1-i], WindowName(typ)+" symmetry", 1e-12)
+		}
+		// A periodic window is a symmetric window with one more value, without the last value.
+		per, err := WindowSlice(typ, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		compareSliceFloat(t, sym[:10], per, WindowName(typ)+" periodic", 1e-12)
+	}
+
+	// The original windows are unchanged.
+	w, _ = WindowSlice(Hamming, 16)
+	compareSliceFloat(t, HammingWindow(16), w, "hamming", 1e-15)
+
+	if _, err := WindowSlice(1000, 10); err == nil {
+		t.Fatal("expected error for unknown window type")
+	}
+}
+
+func TestWindowRegistry(t *testing.T) {
+
+	typ, err := WindowByName("hamming-sym")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if typ != Hamming|Symmetric {
+		t.Fatalf("expected type %d, got %d", Hamming|Symmetric, typ)
+	}
+	if WindowName(typ) != "hamming-sym" {
+		t.Fatalf("expected name hamming-sym, got %s", WindowName(typ))
+	}
+
+	k5, err := WindowByName("kaiser(5)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := WindowByName("kaiser(5)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k5 != again || k5 <= Povey {
+		t.Fatalf("expected the same new window type, got %d and %d", k5, again)
+	}
+	w, err := WindowSlice(k5|Symmetric, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareSliceFloat(t, KaiserWindow(7, 5, true), w, "kaiser(5)", 1e-15)
+
+	win := NewWindowProc(80, 200, k5, false)
+	if win.err != nil {
+		t.Fatal(win.err)
+	}
+
+	sq, err := RegisterWindow("square", func(n int, sym bool) []float64 { return RectangularWindow(n) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if typ, _ := WindowByName("square"); typ != sq {
+		t.Fatalf("expected type %d, got %d", sq, typ)
+	}
+	if _, err := RegisterWindow("hamming", nil); err == nil {
+		t.Fatal("expected error registering an existing window")
+	}
+	for _, name := range []string{"foo", "hamming(2)", "kaiser(x)"} {
+		if _, err := WindowByName(name); err == nil {
+			t.Fatalf("expected error for window name %s", name)
+		}
+	}
+}